@@ -0,0 +1,379 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/storage"
+)
+
+// BatchEvent is one per-file update pushed to a batch's stream
+// subscribers. A Status of "complete" marks the end of the batch
+// itself rather than a single file.
+type BatchEvent struct {
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status"`
+	Score  int    `json:"score,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchStatusResponse is the wire response for GET /batches/{id}.
+type BatchStatusResponse struct {
+	Status  storage.BatchStatus `json:"status"`
+	Total   int                 `json:"total"`
+	Done    int                 `json:"done"`
+	Failed  int                 `json:"failed"`
+	PerFile []storage.BatchFile `json:"perFile"`
+}
+
+type batchJob struct {
+	ctx     context.Context
+	batchID string
+	name    string
+	path    string
+	key     storage.AnswerKey
+}
+
+// BatchProcessor grades bulk ZIP uploads through a bounded pool of
+// worker goroutines, sized from runtime.NumCPU(), and fans out
+// per-file completion events to any /batches/{id}/stream subscribers.
+type BatchProcessor struct {
+	store     *storage.Store
+	dp        *DocumentProcessor
+	uploadDir string
+	jobs      chan batchJob
+
+	mutex   sync.Mutex
+	cancels map[string]context.CancelFunc
+	subs    map[string][]chan BatchEvent
+}
+
+// NewBatchProcessor starts the worker pool and returns a processor
+// ready to accept Submit calls. uploadDir is where raw uploads are
+// saved so a pending file can be reread and requeued after a restart.
+func NewBatchProcessor(store *storage.Store, dp *DocumentProcessor, uploadDir string) *BatchProcessor {
+	workers := runtime.NumCPU()
+	if workers < 1 {
+		workers = 1
+	}
+
+	bp := &BatchProcessor{
+		store:     store,
+		dp:        dp,
+		uploadDir: uploadDir,
+		jobs:      make(chan batchJob, workers*4),
+		cancels:   make(map[string]context.CancelFunc),
+		subs:      make(map[string][]chan BatchEvent),
+	}
+	for i := 0; i < workers; i++ {
+		go bp.worker()
+	}
+	return bp
+}
+
+func (bp *BatchProcessor) worker() {
+	for job := range bp.jobs {
+		bp.runJob(job)
+	}
+}
+
+func (bp *BatchProcessor) runJob(job batchJob) {
+	if err := job.ctx.Err(); err != nil {
+		bp.complete(job.batchID, job.name, "", 0, err.Error())
+		return
+	}
+
+	data, err := os.ReadFile(job.path)
+	if err != nil {
+		bp.complete(job.batchID, job.name, "", 0, fmt.Sprintf("read upload: %v", err))
+		return
+	}
+
+	result, err := bp.dp.ProcessDocument(job.ctx, data, job.key)
+	if err != nil {
+		bp.complete(job.batchID, job.name, "", 0, err.Error())
+		return
+	}
+
+	resultID, err := bp.dp.saveResult(job.name, data, result)
+	if err != nil {
+		log.Printf("batch %s: failed to save result for %s: %v", job.batchID, job.name, err)
+	}
+
+	bp.complete(job.batchID, job.name, resultID, result.Score, "")
+}
+
+func (bp *BatchProcessor) complete(batchID, name, resultID string, score int, fileErr string) {
+	if err := bp.store.CompleteBatchFile(batchID, name, resultID, score, fileErr); err != nil {
+		log.Printf("batch %s: failed to record result for %s: %v", batchID, name, err)
+	}
+
+	status := "done"
+	if fileErr != "" {
+		status = "failed"
+	}
+	bp.publish(batchID, BatchEvent{Name: name, Status: status, Score: score, Error: fileErr})
+
+	batch, err := bp.store.GetBatch(batchID)
+	if err == nil && batch.Done+batch.Failed >= batch.Total {
+		bp.finish(batchID)
+	}
+}
+
+func (bp *BatchProcessor) finish(batchID string) {
+	if err := bp.store.FinishBatch(batchID); err != nil {
+		log.Printf("batch %s: failed to mark finished: %v", batchID, err)
+	}
+	bp.publish(batchID, BatchEvent{Status: "complete"})
+	bp.closeSubs(batchID)
+
+	bp.mutex.Lock()
+	delete(bp.cancels, batchID)
+	bp.mutex.Unlock()
+}
+
+// Submit unpacks a ZIP upload into individual graded jobs, saves each
+// entry to uploadDir, persists the batch and its file list, and
+// enqueues every file on the worker pool. It returns as soon as the
+// batch is recorded; grading happens asynchronously.
+func (bp *BatchProcessor) Submit(zipData []byte, key storage.AnswerKey) (storage.Batch, error) {
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return storage.Batch{}, fmt.Errorf("invalid zip upload: %w", err)
+	}
+
+	id := fmt.Sprintf("batch_%d", time.Now().UnixNano())
+	batchDir := filepath.Join(bp.uploadDir, id)
+	if err := os.MkdirAll(batchDir, 0o755); err != nil {
+		return storage.Batch{}, fmt.Errorf("create upload dir: %w", err)
+	}
+
+	var files []storage.BatchFile
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !isImageName(f.Name) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return storage.Batch{}, fmt.Errorf("read %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return storage.Batch{}, fmt.Errorf("read %s: %w", f.Name, err)
+		}
+
+		// filepath.Base strips any directory components the zip entry
+		// claims, so a crafted "../../etc/passwd" path can't write
+		// outside batchDir.
+		diskPath := filepath.Join(batchDir, filepath.Base(f.Name))
+		if err := os.WriteFile(diskPath, data, 0o644); err != nil {
+			return storage.Batch{}, fmt.Errorf("save %s: %w", f.Name, err)
+		}
+
+		files = append(files, storage.BatchFile{Name: f.Name, Path: diskPath})
+	}
+	if len(files) == 0 {
+		return storage.Batch{}, fmt.Errorf("zip upload contained no image files")
+	}
+
+	return bp.createAndEnqueue(id, files, key)
+}
+
+// SubmitFiles is Submit's counterpart for a multipart form carrying the
+// images directly (one form field per file) rather than bundled into a
+// ZIP. name/data pairs are matched by index.
+func (bp *BatchProcessor) SubmitFiles(names []string, datas [][]byte, key storage.AnswerKey) (storage.Batch, error) {
+	id := fmt.Sprintf("batch_%d", time.Now().UnixNano())
+	batchDir := filepath.Join(bp.uploadDir, id)
+	if err := os.MkdirAll(batchDir, 0o755); err != nil {
+		return storage.Batch{}, fmt.Errorf("create upload dir: %w", err)
+	}
+
+	var files []storage.BatchFile
+	for i, name := range names {
+		if !isImageName(name) {
+			continue
+		}
+
+		// filepath.Base strips any directory components the client
+		// claims, so a crafted "../../etc/passwd" name can't write
+		// outside batchDir.
+		diskPath := filepath.Join(batchDir, filepath.Base(name))
+		if err := os.WriteFile(diskPath, datas[i], 0o644); err != nil {
+			return storage.Batch{}, fmt.Errorf("save %s: %w", name, err)
+		}
+
+		files = append(files, storage.BatchFile{Name: name, Path: diskPath})
+	}
+	if len(files) == 0 {
+		return storage.Batch{}, fmt.Errorf("upload contained no image files")
+	}
+
+	return bp.createAndEnqueue(id, files, key)
+}
+
+func (bp *BatchProcessor) createAndEnqueue(id string, files []storage.BatchFile, key storage.AnswerKey) (storage.Batch, error) {
+	batch, err := bp.store.CreateBatch(storage.Batch{ID: id, KeyID: key.ID}, files)
+	if err != nil {
+		return storage.Batch{}, err
+	}
+
+	bp.enqueue(batch.ID, files, key)
+	return batch, nil
+}
+
+// enqueue registers batchID's cancel func synchronously, so a Cancel
+// call right after Submit returns is never missed, then feeds its jobs
+// onto bp.jobs from a goroutine: once the pool is busy, jobs is bounded
+// and sends block, and this must not block Submit's caller.
+func (bp *BatchProcessor) enqueue(batchID string, files []storage.BatchFile, key storage.AnswerKey) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bp.mutex.Lock()
+	bp.cancels[batchID] = cancel
+	bp.mutex.Unlock()
+
+	go func() {
+		for _, f := range files {
+			bp.jobs <- batchJob{ctx: ctx, batchID: batchID, name: f.Name, path: f.Path, key: key}
+		}
+	}()
+}
+
+// RequeuePending re-enqueues the still-pending files of any batch that
+// was left "running" when the service last stopped, so an in-flight
+// upload survives a restart.
+func (bp *BatchProcessor) RequeuePending() error {
+	batches, err := bp.store.ListRunningBatches()
+	if err != nil {
+		return fmt.Errorf("list running batches: %w", err)
+	}
+
+	for _, batch := range batches {
+		var key storage.AnswerKey
+		if batch.KeyID != "" {
+			key, err = bp.store.GetKey(batch.KeyID)
+			if err != nil {
+				log.Printf("batch %s: failed to reload answer key %s: %v", batch.ID, batch.KeyID, err)
+			}
+		}
+
+		files, err := bp.store.ListBatchFiles(batch.ID)
+		if err != nil {
+			log.Printf("batch %s: failed to list files for requeue: %v", batch.ID, err)
+			continue
+		}
+
+		var pending []storage.BatchFile
+		for _, f := range files {
+			if f.Status == storage.BatchFilePending {
+				pending = append(pending, f)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		log.Printf("batch %s: requeuing %d pending file(s)", batch.ID, len(pending))
+		bp.enqueue(batch.ID, pending, key)
+	}
+	return nil
+}
+
+// Status reports a batch's progress and per-file outcomes.
+func (bp *BatchProcessor) Status(id string) (*BatchStatusResponse, error) {
+	batch, err := bp.store.GetBatch(id)
+	if err != nil {
+		return nil, err
+	}
+	files, err := bp.store.ListBatchFiles(id)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchStatusResponse{Status: batch.Status, Total: batch.Total, Done: batch.Done, Failed: batch.Failed, PerFile: files}, nil
+}
+
+// Cancel stops a running batch: files not yet picked up by a worker
+// are failed without being processed, since runJob checks ctx.Err()
+// before doing any work.
+func (bp *BatchProcessor) Cancel(id string) error {
+	bp.mutex.Lock()
+	cancel, ok := bp.cancels[id]
+	bp.mutex.Unlock()
+	if !ok {
+		return storage.ErrBatchNotFound
+	}
+
+	if err := bp.store.CancelBatch(id); err != nil {
+		return err
+	}
+	cancel()
+	return nil
+}
+
+// Subscribe registers a channel for batch id's events. The returned
+// func must be called to unsubscribe and release the channel.
+func (bp *BatchProcessor) Subscribe(batchID string) (<-chan BatchEvent, func()) {
+	ch := make(chan BatchEvent, 16)
+
+	bp.mutex.Lock()
+	bp.subs[batchID] = append(bp.subs[batchID], ch)
+	bp.mutex.Unlock()
+
+	unsubscribe := func() {
+		bp.mutex.Lock()
+		defer bp.mutex.Unlock()
+		subs := bp.subs[batchID]
+		for i, s := range subs {
+			if s == ch {
+				bp.subs[batchID] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+	}
+	return ch, unsubscribe
+}
+
+func (bp *BatchProcessor) publish(batchID string, event BatchEvent) {
+	bp.mutex.Lock()
+	subs := append([]chan BatchEvent(nil), bp.subs[batchID]...)
+	bp.mutex.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- event:
+		default: // a slow/gone subscriber shouldn't stall grading
+		}
+	}
+}
+
+func (bp *BatchProcessor) closeSubs(batchID string) {
+	bp.mutex.Lock()
+	subs := bp.subs[batchID]
+	delete(bp.subs, batchID)
+	bp.mutex.Unlock()
+
+	for _, ch := range subs {
+		close(ch)
+	}
+}
+
+func isImageName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestIsImageName(t *testing.T) {
+	cases := []struct {
+		name string
+		want bool
+	}{
+		{"sheet1.jpg", true},
+		{"sheet1.JPEG", true},
+		{"sheet1.png", true},
+		{"sheet1.pdf", false},
+		{"sheet1", false},
+		{"readme.txt", false},
+	}
+
+	for _, c := range cases {
+		if got := isImageName(c.name); got != c.want {
+			t.Errorf("isImageName(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
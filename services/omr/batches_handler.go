@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/storage"
+)
+
+// BatchesAPI exposes bulk-upload grading: POST /batches to submit a
+// batch, either as a single ZIP of images ("file") or as individually
+// attached images ("files"); GET /batches/{id} for progress; GET
+// /batches/{id}/stream for live per-file updates over SSE; and DELETE
+// /batches/{id} to cancel.
+type BatchesAPI struct {
+	bp    *BatchProcessor
+	store *storage.Store
+}
+
+// NewBatchesAPI builds a BatchesAPI over bp, resolving keyId uploads
+// against store.
+func NewBatchesAPI(bp *BatchProcessor, store *storage.Store) *BatchesAPI {
+	return &BatchesAPI{bp: bp, store: store}
+}
+
+// Collection handles POST /batches.
+func (a *BatchesAPI) Collection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(128 << 20); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse form: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var key storage.AnswerKey
+	if keyID := r.FormValue("keyId"); keyID != "" {
+		var err error
+		key, err = a.store.GetKey(keyID)
+		if err != nil {
+			writeKeyError(w, err)
+			return
+		}
+	}
+
+	// "files" carries individual images submitted directly (no ZIP
+	// wrapper); "file" is the original single-ZIP-upload path.
+	var (
+		batch storage.Batch
+		err   error
+	)
+	if fileHeaders := r.MultipartForm.File["files"]; len(fileHeaders) > 0 {
+		batch, err = a.submitFiles(fileHeaders, key)
+	} else {
+		batch, err = a.submitZip(r, key)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, map[string]string{"batchId": batch.ID})
+}
+
+func (a *BatchesAPI) submitZip(r *http.Request, key storage.AnswerKey) (storage.Batch, error) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return storage.Batch{}, fmt.Errorf("failed to get file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return storage.Batch{}, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	return a.bp.Submit(data, key)
+}
+
+func (a *BatchesAPI) submitFiles(headers []*multipart.FileHeader, key storage.AnswerKey) (storage.Batch, error) {
+	names := make([]string, 0, len(headers))
+	datas := make([][]byte, 0, len(headers))
+
+	for _, h := range headers {
+		f, err := h.Open()
+		if err != nil {
+			return storage.Batch{}, fmt.Errorf("failed to open %s: %w", h.Filename, err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return storage.Batch{}, fmt.Errorf("failed to read %s: %w", h.Filename, err)
+		}
+
+		names = append(names, h.Filename)
+		datas = append(datas, data)
+	}
+
+	return a.bp.SubmitFiles(names, datas, key)
+}
+
+// Item handles GET /batches/{id}, GET /batches/{id}/stream, and
+// DELETE /batches/{id}.
+func (a *BatchesAPI) Item(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/batches/")
+	if strings.HasSuffix(path, "/stream") {
+		a.stream(w, r, strings.TrimSuffix(path, "/stream"))
+		return
+	}
+	id := path
+
+	switch r.Method {
+	case http.MethodGet:
+		status, err := a.bp.Status(id)
+		if err != nil {
+			writeBatchError(w, err)
+			return
+		}
+		writeJSON(w, status)
+
+	case http.MethodDelete:
+		if err := a.bp.Cancel(id); err != nil {
+			writeBatchError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// stream pushes BatchEvents for id as server-sent events until the
+// batch completes or the client disconnects. It subscribes before
+// checking the batch's current status so a completion racing the
+// subscribe is never missed; if the batch already finished by the
+// time we check, the completion event is synthesized here since
+// nothing will ever publish to a subscriber that arrived late.
+func (a *BatchesAPI) stream(w http.ResponseWriter, r *http.Request, id string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := a.bp.Subscribe(id)
+	defer unsubscribe()
+
+	status, err := a.bp.Status(id)
+	if err != nil {
+		writeBatchError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	if status.Status != storage.BatchRunning {
+		payload, _ := json.Marshal(BatchEvent{Status: "complete"})
+		fmt.Fprintf(w, "data: %s\n\n", payload)
+		flusher.Flush()
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, _ := json.Marshal(event)
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			flusher.Flush()
+			if event.Status == "complete" {
+				return
+			}
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeBatchError(w http.ResponseWriter, err error) {
+	if err == storage.ErrBatchNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+
+	"gocv.io/x/gocv"
+
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/storage"
+)
+
+// bubbleCandidate is one contour the grid detector considers to be a
+// bubble, along with how full it is.
+type bubbleCandidate struct {
+	Center    image.Point
+	Radius    int
+	FillRatio float64
+}
+
+// gradeBubbleGrid replaces the old HoughCircles mock: it thresholds
+// the OMR region with Otsu, isolates bubble-shaped contours, clusters
+// them into rows and columns, and scores each row by fill ratio
+// rather than cycling through a fixed answer list.
+func gradeBubbleGrid(gray gocv.Mat, layout storage.BubbleLayout) []string {
+	candidates := detectBubbleCandidates(gray, layout.MinRadius, layout.MaxRadius)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	rowGroups := groupByGap(candidateValues(candidates, func(c bubbleCandidate) float64 { return float64(c.Center.Y) }))
+	rowGroups = boundRowGroups(rowGroups, layout.Rows)
+	choices := bubbleChoiceLabels(layout.ChoicesPerRow)
+
+	answers := make([]string, 0, len(rowGroups))
+	for _, rowIdx := range rowGroups {
+		row := make([]bubbleCandidate, len(rowIdx))
+		for i, idx := range rowIdx {
+			row[i] = candidates[idx]
+		}
+
+		colGroups := groupByGap(candidateValues(row, func(c bubbleCandidate) float64 { return float64(c.Center.X) }))
+		columns := make([]bubbleCandidate, len(colGroups))
+		for i, colIdx := range colGroups {
+			columns[i] = bestOfColumn(row, colIdx)
+		}
+		sort.Slice(columns, func(i, j int) bool { return columns[i].Center.X < columns[j].Center.X })
+
+		ratios := make([]float64, len(columns))
+		for i, c := range columns {
+			ratios[i] = c.FillRatio
+		}
+		answers = append(answers, determineRowAnswer(ratios, choices, layout.FillThreshold))
+	}
+
+	return answers
+}
+
+// boundRowGroups sanity-checks the detector's row clustering against
+// the configured expectation: extra trailing groups past expectedRows
+// are almost always noise contours below the last real row, so
+// they're dropped; clustering fewer rows than expected is padded with
+// empty groups, which determineRowAnswer reports as "blank".
+func boundRowGroups(rowGroups [][]int, expectedRows int) [][]int {
+	if expectedRows <= 0 || len(rowGroups) == expectedRows {
+		return rowGroups
+	}
+	if len(rowGroups) > expectedRows {
+		return rowGroups[:expectedRows]
+	}
+
+	padded := make([][]int, expectedRows)
+	copy(padded, rowGroups)
+	return padded
+}
+
+// bestOfColumn collapses candidates that clustered into the same
+// column (duplicate contours for one bubble) down to the one with the
+// highest fill ratio.
+func bestOfColumn(row []bubbleCandidate, idx []int) bubbleCandidate {
+	best := row[idx[0]]
+	for _, i := range idx[1:] {
+		if row[i].FillRatio > best.FillRatio {
+			best = row[i]
+		}
+	}
+	return best
+}
+
+func bubbleChoiceLabels(choicesPerRow int) []string {
+	if choicesPerRow <= 0 {
+		choicesPerRow = storage.DefaultBubbleLayout.ChoicesPerRow
+	}
+	labels := make([]string, choicesPerRow)
+	for i := range labels {
+		labels[i] = string(rune('A' + i))
+	}
+	return labels
+}
+
+// determineRowAnswer applies the fill-ratio decision rule: a choice is
+// "filled" only when its ratio clears floor AND is at least 1.4x the
+// row's second-highest ratio. Otherwise every choice clearing floor is
+// reported as a first-class sorted, comma-joined set (e.g. "A,C") via
+// markedChoices, the same format scoreAgainstKey expects for a
+// multi-select key, and "blank" for a row with nothing above floor.
+func determineRowAnswer(ratios []float64, choices []string, floor float64) string {
+	if floor <= 0 {
+		floor = storage.DefaultBubbleLayout.FillThreshold
+	}
+	const marginRatio = 1.4
+
+	if len(ratios) == 0 {
+		return "blank"
+	}
+
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return ratios[order[i]] > ratios[order[j]] })
+
+	top := ratios[order[0]]
+	if top < floor {
+		return "blank"
+	}
+
+	aboveFloor := 0
+	for _, r := range ratios {
+		if r >= floor {
+			aboveFloor++
+		}
+	}
+	if aboveFloor > 1 {
+		second := ratios[order[1]]
+		if top < marginRatio*second {
+			return markedChoices(ratios, choices, floor)
+		}
+	}
+
+	if order[0] < len(choices) {
+		return choices[order[0]]
+	}
+	return "blank"
+}
+
+// markedChoices returns the sorted, comma-joined labels of every
+// choice whose ratio clears floor. This is the first-class "multi"
+// answer value the request asks for: an explicit choice set rather
+// than a sentinel string, since it's also what scoreAgainstKey's
+// multi-select comparison already expects.
+func markedChoices(ratios []float64, choices []string, floor float64) string {
+	var marked []string
+	for i, r := range ratios {
+		if r >= floor && i < len(choices) {
+			marked = append(marked, choices[i])
+		}
+	}
+	sort.Strings(marked)
+	return strings.Join(marked, ",")
+}
+
+// detectBubbleCandidates finds bubble-shaped blobs in gray: Otsu
+// threshold, a morphological open to drop thin noise and isolate
+// bubble interiors, then contour filtering by aspect ratio, area and
+// solidity.
+func detectBubbleCandidates(gray gocv.Mat, minRadius, maxRadius int) []bubbleCandidate {
+	if minRadius <= 0 {
+		minRadius = storage.DefaultBubbleLayout.MinRadius
+	}
+	if maxRadius <= 0 {
+		maxRadius = storage.DefaultBubbleLayout.MaxRadius
+	}
+
+	thresh := gocv.NewMat()
+	defer thresh.Close()
+	gocv.Threshold(gray, &thresh, 0, 255, gocv.ThresholdBinaryInv+gocv.ThresholdOtsu)
+
+	kernel := gocv.GetStructuringElement(gocv.MorphEllipse, image.Pt(3, 3))
+	defer kernel.Close()
+	opened := gocv.NewMat()
+	defer opened.Close()
+	gocv.MorphologyEx(thresh, &opened, gocv.MorphOpen, kernel)
+
+	contours := gocv.FindContours(opened, gocv.RetrievalList, gocv.ChainApproxSimple)
+	defer contours.Close()
+
+	minArea := math.Pi * float64(minRadius*minRadius)
+	maxArea := math.Pi * float64(maxRadius*maxRadius)
+
+	var candidates []bubbleCandidate
+	for i := 0; i < contours.Size(); i++ {
+		contour := contours.At(i)
+
+		area := gocv.ContourArea(contour)
+		if area < minArea || area > maxArea {
+			continue
+		}
+
+		rect := gocv.BoundingRect(contour)
+		if rect.Dy() == 0 {
+			continue
+		}
+		aspect := float64(rect.Dx()) / float64(rect.Dy())
+		if aspect < 0.75 || aspect > 1.3 {
+			continue
+		}
+
+		if contourSolidity(contour, area) < 0.85 {
+			continue
+		}
+
+		center := image.Pt(rect.Min.X+rect.Dx()/2, rect.Min.Y+rect.Dy()/2)
+		radius := (rect.Dx() + rect.Dy()) / 4
+
+		candidates = append(candidates, bubbleCandidate{
+			Center:    center,
+			Radius:    radius,
+			FillRatio: fillRatio(opened, center, radius),
+		})
+	}
+
+	return candidates
+}
+
+// contourSolidity is the ratio of a contour's area to its convex
+// hull's area; a filled-in disc is close to 1, while an irregular
+// smudge or a partial arc is well below it.
+func contourSolidity(contour gocv.PointVector, area float64) float64 {
+	hull := gocv.NewMat()
+	defer hull.Close()
+	gocv.ConvexHull(contour, &hull, true, true)
+
+	hullPoints := gocv.NewPointVectorFromMat(hull)
+	defer hullPoints.Close()
+	hullArea := gocv.ContourArea(hullPoints)
+
+	if hullArea == 0 {
+		return 0
+	}
+	return area / hullArea
+}
+
+// fillRatio is nonZeroCount(mask ∩ bubble) / area(bubble), using the
+// bubble's own circular footprint rather than its bounding box so a
+// skewed crop doesn't dilute the ratio with background pixels.
+func fillRatio(mask gocv.Mat, center image.Point, radius int) float64 {
+	circleMask := gocv.NewMatWithSize(mask.Rows(), mask.Cols(), gocv.MatTypeCV8U)
+	defer circleMask.Close()
+	circleMask.SetTo(gocv.NewScalar(0, 0, 0, 0))
+	gocv.Circle(&circleMask, center, radius, color.RGBA{R: 255, G: 255, B: 255, A: 255}, -1)
+
+	intersection := gocv.NewMat()
+	defer intersection.Close()
+	gocv.BitwiseAnd(mask, circleMask, &intersection)
+
+	nonZero := gocv.CountNonZero(intersection)
+	area := math.Pi * float64(radius*radius)
+	if area == 0 {
+		return 0
+	}
+	return float64(nonZero) / area
+}
+
+// candidateValues extracts one coordinate from each candidate for
+// groupByGap.
+func candidateValues(candidates []bubbleCandidate, get func(bubbleCandidate) float64) []float64 {
+	values := make([]float64, len(candidates))
+	for i, c := range candidates {
+		values[i] = get(c)
+	}
+	return values
+}
+
+// groupByGap sorts values and splits them into groups wherever the
+// gap between consecutive values exceeds a MAD-scaled threshold, in
+// place of the previous hard-coded 30px row split. It returns each
+// group as a list of indices into the original (unsorted) values.
+func groupByGap(values []float64) [][]int {
+	if len(values) == 0 {
+		return nil
+	}
+
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+
+	gaps := make([]float64, 0, len(order)-1)
+	for i := 1; i < len(order); i++ {
+		gaps = append(gaps, values[order[i]]-values[order[i-1]])
+	}
+	threshold := madGapThreshold(gaps)
+
+	groups := [][]int{{order[0]}}
+	for i := 1; i < len(order); i++ {
+		gap := values[order[i]] - values[order[i-1]]
+		if gap > threshold {
+			groups = append(groups, []int{order[i]})
+		} else {
+			last := len(groups) - 1
+			groups[last] = append(groups[last], order[i])
+		}
+	}
+	return groups
+}
+
+// madGapThreshold picks a split threshold from the median absolute
+// deviation of consecutive gaps: a gap more than ~3 MADs above the
+// median gap is a new row/column rather than noise within one.
+func madGapThreshold(gaps []float64) float64 {
+	if len(gaps) == 0 {
+		return 30 // single candidate; fall back to the old fixed spacing
+	}
+
+	median := medianFloat(gaps)
+	deviations := make([]float64, len(gaps))
+	for i, g := range gaps {
+		deviations[i] = math.Abs(g - median)
+	}
+	mad := medianFloat(deviations)
+
+	if mad == 0 {
+		return median * 1.5
+	}
+	return median + 3*mad
+}
+
+func medianFloat(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
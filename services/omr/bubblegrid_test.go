@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetermineRowAnswer(t *testing.T) {
+	choices := []string{"A", "B", "C", "D", "E"}
+
+	cases := []struct {
+		name   string
+		ratios []float64
+		want   string
+	}{
+		{"clear single mark", []float64{0.9, 0.1, 0.1, 0.1, 0.1}, "A"},
+		{"nothing above floor", []float64{0.2, 0.1, 0.1, 0.1, 0.1}, "blank"},
+		{"ambiguous tie reported as set", []float64{0.8, 0.75, 0.1, 0.1, 0.1}, "A,B"},
+		{"dominant mark beats a faint second", []float64{0.9, 0.2, 0.1, 0.1, 0.1}, "A"},
+		{"no ratios at all", []float64{}, "blank"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := determineRowAnswer(c.ratios, choices, 0.5); got != c.want {
+				t.Errorf("determineRowAnswer(%v) = %q, want %q", c.ratios, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMarkedChoices(t *testing.T) {
+	choices := []string{"A", "B", "C", "D", "E"}
+	ratios := []float64{0.8, 0.1, 0.7, 0.1, 0.9}
+
+	got := markedChoices(ratios, choices, 0.5)
+	want := "A,C,E"
+	if got != want {
+		t.Errorf("markedChoices = %q, want %q", got, want)
+	}
+}
+
+func TestBoundRowGroups(t *testing.T) {
+	cases := []struct {
+		name         string
+		rowGroups    [][]int
+		expectedRows int
+		want         [][]int
+	}{
+		{"matches expectation", [][]int{{0}, {1}}, 2, [][]int{{0}, {1}}},
+		{"no expectation configured", [][]int{{0}, {1}, {2}}, 0, [][]int{{0}, {1}, {2}}},
+		{"trims trailing noise groups", [][]int{{0}, {1}, {2}}, 2, [][]int{{0}, {1}}},
+		{"pads missing rows as blank", [][]int{{0}}, 3, [][]int{{0}, nil, nil}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := boundRowGroups(c.rowGroups, c.expectedRows); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("boundRowGroups(%v, %d) = %v, want %v", c.rowGroups, c.expectedRows, got, c.want)
+			}
+		})
+	}
+}
+
+func TestGroupByGap(t *testing.T) {
+	values := []float64{10, 12, 11, 100, 102, 101}
+
+	groups := groupByGap(values)
+	if len(groups) != 2 {
+		t.Fatalf("groupByGap produced %d groups, want 2: %v", len(groups), groups)
+	}
+	for _, g := range groups {
+		if len(g) != 3 {
+			t.Errorf("group %v has %d members, want 3", g, len(g))
+		}
+	}
+}
+
+func TestMadGapThreshold(t *testing.T) {
+	if got := madGapThreshold(nil); got != 30 {
+		t.Errorf("madGapThreshold(nil) = %v, want 30 (fixed fallback)", got)
+	}
+
+	// Uniform gaps: zero MAD falls back to 1.5x the median gap.
+	if got := madGapThreshold([]float64{10, 10, 10}); got != 15 {
+		t.Errorf("madGapThreshold(uniform) = %v, want 15", got)
+	}
+}
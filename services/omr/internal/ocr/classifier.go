@@ -0,0 +1,60 @@
+package ocr
+
+import (
+	"fmt"
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+// DirectionClassifier is a TextClassifier backed by a small CNN that
+// predicts whether a cropped text line is right-side-up or rotated
+// 180°, the same role PaddleOCR's "cls" stage plays between detection
+// and recognition.
+type DirectionClassifier struct {
+	net       gocv.Net
+	inputSize image.Point
+	thresh    float32
+}
+
+// NewDirectionClassifier loads an ONNX 0°/180° classifier model.
+func NewDirectionClassifier(modelPath string) (*DirectionClassifier, error) {
+	net := gocv.ReadNetFromONNX(modelPath)
+	if net.Empty() {
+		return nil, fmt.Errorf("ocr: failed to load classifier model %q", modelPath)
+	}
+	return &DirectionClassifier{
+		net:       net,
+		inputSize: image.Pt(192, 48),
+		thresh:    0.9,
+	}, nil
+}
+
+// Close releases the underlying network.
+func (c *DirectionClassifier) Close() error {
+	return c.net.Close()
+}
+
+// IsRotated reports whether crop should be flipped 180° before
+// recognition, along with the model's confidence in that decision.
+func (c *DirectionClassifier) IsRotated(crop gocv.Mat) (bool, float64, error) {
+	blob := gocv.BlobFromImage(crop, 1.0/255.0, c.inputSize, gocv.NewScalar(0.5, 0.5, 0.5, 0), true, false)
+	defer blob.Close()
+
+	c.net.SetInput(blob, "")
+	out := c.net.Forward("")
+	defer out.Close()
+
+	// Two-class softmax output: [p(0deg), p(180deg)].
+	pRotated := out.GetFloatAt(0, 1)
+	return pRotated >= c.thresh, float64(pRotated), nil
+}
+
+// flip180 returns a new Mat holding crop rotated 180°, used once a
+// DirectionClassifier has flagged it as upside down. crop itself is
+// left unchanged.
+func flip180(crop gocv.Mat) gocv.Mat {
+	flipped := gocv.NewMat()
+	gocv.Flip(crop, &flipped, -1)
+	return flipped
+}
@@ -0,0 +1,114 @@
+package ocr
+
+import (
+	"fmt"
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+// DBDetector is a TextDetector backed by a Differentiable Binarization
+// (DB) model, the same family PaddleOCR uses for its detection stage:
+// the network predicts a per-pixel text probability map which is
+// thresholded and contoured to recover box candidates.
+type DBDetector struct {
+	net         gocv.Net
+	inputSize   int
+	binThresh   float32
+	boxThresh   float32
+	unclipRatio float64
+}
+
+// NewDBDetector loads an ONNX DB detection model from modelPath via
+// gocv's DNN module. inputSize is the square side the model expects
+// (PaddleOCR's det models are typically trained at 960).
+func NewDBDetector(modelPath string, inputSize int) (*DBDetector, error) {
+	net := gocv.ReadNetFromONNX(modelPath)
+	if net.Empty() {
+		return nil, fmt.Errorf("ocr: failed to load detector model %q", modelPath)
+	}
+	return &DBDetector{
+		net:         net,
+		inputSize:   inputSize,
+		binThresh:   0.3,
+		boxThresh:   0.6,
+		unclipRatio: 1.5,
+	}, nil
+}
+
+// Close releases the underlying network.
+func (d *DBDetector) Close() error {
+	return d.net.Close()
+}
+
+// Detect runs the DB model over img and returns the recovered text
+// quads in original image coordinates.
+func (d *DBDetector) Detect(img gocv.Mat) ([]Detection, error) {
+	blob := gocv.BlobFromImage(img, 1.0/255.0, image.Pt(d.inputSize, d.inputSize),
+		gocv.NewScalar(0.485, 0.456, 0.406, 0), true, false)
+	defer blob.Close()
+
+	d.net.SetInput(blob, "")
+	prob := d.net.Forward("")
+	defer prob.Close()
+
+	binary := gocv.NewMat()
+	defer binary.Close()
+	gocv.Threshold(prob, &binary, d.binThresh, 1.0, gocv.ThresholdBinary)
+
+	binary8u := gocv.NewMat()
+	defer binary8u.Close()
+	binary.ConvertTo(&binary8u, gocv.MatTypeCV8U)
+
+	contours := gocv.FindContours(binary8u, gocv.RetrievalList, gocv.ChainApproxSimple)
+	defer contours.Close()
+
+	scaleX := float64(img.Cols()) / float64(d.inputSize)
+	scaleY := float64(img.Rows()) / float64(d.inputSize)
+
+	var detections []Detection
+	for i := 0; i < contours.Size(); i++ {
+		contour := contours.At(i)
+		rect := gocv.MinAreaRect(contour)
+		if rect.Width*rect.Height < 16 {
+			continue
+		}
+
+		box := quadFromRotatedRect(rect, scaleX, scaleY)
+		detections = append(detections, Detection{
+			Box:        unclip(box, d.unclipRatio),
+			Confidence: float64(d.boxThresh),
+		})
+	}
+
+	return detections, nil
+}
+
+func quadFromRotatedRect(rect gocv.RotatedRect, scaleX, scaleY float64) Quad {
+	var quad Quad
+	for i, p := range rect.Points {
+		quad[i] = Point{X: float64(p.X) * scaleX, Y: float64(p.Y) * scaleY}
+	}
+	return quad
+}
+
+// unclip expands a tightly fit box outward by ratio, compensating for
+// the DB model's tendency to shrink text regions during training.
+func unclip(box Quad, ratio float64) Quad {
+	cx, cy := 0.0, 0.0
+	for _, p := range box {
+		cx += p.X
+		cy += p.Y
+	}
+	cx /= 4
+	cy /= 4
+
+	out := box
+	for i, p := range box {
+		out[i] = Point{
+			X: cx + (p.X-cx)*ratio,
+			Y: cy + (p.Y-cy)*ratio,
+		}
+	}
+	return out
+}
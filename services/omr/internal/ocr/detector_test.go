@@ -0,0 +1,22 @@
+package ocr
+
+import "testing"
+
+func TestUnclip(t *testing.T) {
+	box := Quad{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+
+	out := unclip(box, 2.0)
+
+	want := Quad{{X: -5, Y: -5}, {X: 15, Y: -5}, {X: 15, Y: 15}, {X: -5, Y: 15}}
+	if out != want {
+		t.Errorf("unclip(%v, 2.0) = %v, want %v", box, out, want)
+	}
+}
+
+func TestUnclipIdentityRatio(t *testing.T) {
+	box := Quad{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 5, Y: 9}, {X: 1, Y: 9}}
+
+	if out := unclip(box, 1.0); out != box {
+		t.Errorf("unclip(%v, 1.0) = %v, want unchanged", box, out)
+	}
+}
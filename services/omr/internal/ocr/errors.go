@@ -0,0 +1,5 @@
+package ocr
+
+import "errors"
+
+var errInvalidBox = errors.New("ocr: detected box has zero width or height")
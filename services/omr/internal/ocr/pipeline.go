@@ -0,0 +1,75 @@
+package ocr
+
+import "gocv.io/x/gocv"
+
+// FieldResult is the recognized text for one named field (e.g.
+// "nombre" or "curp") along with the confidence PaddleOCR-style
+// pipelines derive from their recognition stage.
+type FieldResult struct {
+	Text       string
+	Confidence float64
+}
+
+// Pipeline runs the three PaddleOCR stages in sequence: detect text
+// quads, classify and correct their orientation, then recognize the
+// rectified crops. Each stage is swappable so a field can be read with
+// the default DNN models or a test double.
+type Pipeline struct {
+	Detector   TextDetector
+	Classifier TextClassifier
+	Recognizer TextRecognizer
+}
+
+// NewPipeline builds a Pipeline from already-constructed stages.
+func NewPipeline(detector TextDetector, classifier TextClassifier, recognizer TextRecognizer) *Pipeline {
+	return &Pipeline{Detector: detector, Classifier: classifier, Recognizer: recognizer}
+}
+
+// ReadField runs the full pipeline over roi (already cropped to the
+// field's bounding box) and returns the best-confidence line found. If
+// constraint is non-nil and the recognizer supports constrained
+// decoding (as CRNNRecognizer does), decoding is restricted to it.
+func (p *Pipeline) ReadField(roi gocv.Mat, constraint *AlphabetConstraint) (FieldResult, error) {
+	detections, err := p.Detector.Detect(roi)
+	if err != nil {
+		return FieldResult{}, err
+	}
+
+	var best FieldResult
+	for _, det := range detections {
+		crop, err := getRotateCropImage(roi, det.Box)
+		if err != nil {
+			continue
+		}
+
+		rotated, _, err := p.Classifier.IsRotated(crop)
+		if err == nil && rotated {
+			flipped := flip180(crop)
+			crop.Close()
+			crop = flipped
+		}
+
+		rec, err := p.recognize(crop, constraint)
+		crop.Close()
+		if err != nil {
+			continue
+		}
+
+		if rec.Confidence > best.Confidence {
+			best = FieldResult{Text: rec.Text, Confidence: rec.Confidence}
+		}
+	}
+
+	return best, nil
+}
+
+func (p *Pipeline) recognize(crop gocv.Mat, constraint *AlphabetConstraint) (Recognition, error) {
+	if constraint != nil {
+		if constrained, ok := p.Recognizer.(interface {
+			RecognizeConstrained(gocv.Mat, AlphabetConstraint) (Recognition, error)
+		}); ok {
+			return constrained.RecognizeConstrained(crop, *constraint)
+		}
+	}
+	return p.Recognizer.Recognize(crop)
+}
@@ -0,0 +1,137 @@
+package ocr
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+// CRNNRecognizer is a TextRecognizer backed by a CRNN-style model (CNN
+// feature extractor + BiLSTM + CTC decode), the approach PaddleOCR
+// uses for its recognition stage. It decodes a fixed output alphabet
+// and optionally restricts decoding to an AlphabetConstraint for
+// structured fields such as CURP.
+type CRNNRecognizer struct {
+	net       gocv.Net
+	inputSize image.Point
+	alphabet  []rune
+}
+
+// defaultAlphabet is the CRNN model's full output vocabulary: CTC
+// blank first, then digits, uppercase, lowercase and accented
+// characters used in Mexican given names. It contains multi-byte
+// runes, so it must be decoded as []rune (one entry per model output
+// class), never indexed as raw bytes.
+const defaultAlphabet = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyzÁÉÍÓÚÑáéíóúñ "
+
+// NewCRNNRecognizer loads an ONNX CRNN recognition model.
+func NewCRNNRecognizer(modelPath string) (*CRNNRecognizer, error) {
+	net := gocv.ReadNetFromONNX(modelPath)
+	if net.Empty() {
+		return nil, fmt.Errorf("ocr: failed to load recognizer model %q", modelPath)
+	}
+	return &CRNNRecognizer{
+		net:       net,
+		inputSize: image.Pt(320, 48),
+		alphabet:  []rune(defaultAlphabet),
+	}, nil
+}
+
+// Close releases the underlying network.
+func (r *CRNNRecognizer) Close() error {
+	return r.net.Close()
+}
+
+// Recognize decodes the text contained in a rectified text-line crop.
+func (r *CRNNRecognizer) Recognize(crop gocv.Mat) (Recognition, error) {
+	return r.recognize(crop, nil)
+}
+
+// RecognizeConstrained behaves like Recognize but restricts decoding
+// to the given AlphabetConstraint, used for the CURP field where the
+// output is known to be 18 characters of A-Z0-9.
+func (r *CRNNRecognizer) RecognizeConstrained(crop gocv.Mat, constraint AlphabetConstraint) (Recognition, error) {
+	return r.recognize(crop, &constraint)
+}
+
+func (r *CRNNRecognizer) recognize(crop gocv.Mat, constraint *AlphabetConstraint) (Recognition, error) {
+	blob := gocv.BlobFromImage(crop, 1.0/255.0, r.inputSize, gocv.NewScalar(0.5, 0.5, 0.5, 0), true, false)
+	defer blob.Close()
+
+	r.net.SetInput(blob, "")
+	logits := r.net.Forward("")
+	defer logits.Close()
+
+	// logits is [timesteps, alphabetSize]; restrict the per-step argmax
+	// to the allowed charset when a constraint is supplied, then
+	// greedy-decode with CTC collapse of repeats and blanks.
+	timesteps := logits.Rows()
+	indices := make([]int, timesteps)
+	confidences := make([]float64, timesteps)
+	for t := 0; t < timesteps; t++ {
+		indices[t], confidences[t] = argmaxConstrained(logits, t, r.alphabet, constraint)
+	}
+
+	text, confidence := ctcCollapse(indices, confidences, r.alphabet, constraint)
+	return Recognition{Text: text, Confidence: confidence}, nil
+}
+
+// ctcCollapse greedy-decodes a per-timestep sequence of argmax
+// indices: consecutive repeats and the blank index (0) are dropped,
+// surviving indices are decoded through alphabet, and the result is
+// truncated to constraint.Length when a constraint is given. It
+// returns the decoded text and the mean confidence of the indices
+// that survived collapse.
+func ctcCollapse(indices []int, confidences []float64, alphabet []rune, constraint *AlphabetConstraint) (string, float64) {
+	var sb strings.Builder
+	var confSum float64
+	var confCount int
+	prevIdx := -1
+
+	for t, idx := range indices {
+		if idx != prevIdx && idx != 0 {
+			sb.WriteRune(alphabet[idx])
+			confSum += confidences[t]
+			confCount++
+		}
+		prevIdx = idx
+	}
+
+	text := sb.String()
+	if constraint != nil && len(text) > constraint.Length {
+		text = text[:constraint.Length]
+	}
+
+	confidence := 0.0
+	if confCount > 0 {
+		confidence = confSum / float64(confCount)
+	}
+	return text, confidence
+}
+
+// argmaxConstrained returns the highest-scoring alphabet index (and
+// its softmax-ish confidence) at timestep t, skipping any character
+// not present in constraint.Charset when one is given. The CTC blank
+// (index 0) is never skipped, so it can still win a timestep.
+func argmaxConstrained(logits gocv.Mat, t int, alphabet []rune, constraint *AlphabetConstraint) (int, float64) {
+	bestIdx := 0
+	bestVal := float32(-1 << 30)
+
+	for i := 0; i < logits.Cols(); i++ {
+		if i >= len(alphabet) {
+			break
+		}
+		if constraint != nil && i != 0 && !strings.ContainsRune(constraint.Charset, alphabet[i]) {
+			continue
+		}
+		v := logits.GetFloatAt(t, i)
+		if v > bestVal {
+			bestVal = v
+			bestIdx = i
+		}
+	}
+
+	return bestIdx, float64(bestVal)
+}
@@ -0,0 +1,111 @@
+package ocr
+
+import (
+	"math"
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+// logitsMat builds a 1-timestep logits Mat from row, so
+// argmaxConstrained can be exercised without a loaded model.
+func logitsMat(t *testing.T, row []float32) gocv.Mat {
+	t.Helper()
+	mat := gocv.NewMatWithSize(1, len(row), gocv.MatTypeCV32F)
+	t.Cleanup(func() { mat.Close() })
+	for i, v := range row {
+		mat.SetFloatAt(0, i, v)
+	}
+	return mat
+}
+
+func TestArgmaxConstrained(t *testing.T) {
+	alphabet := []rune("-ABC")
+
+	t.Run("unconstrained picks the highest logit", func(t *testing.T) {
+		mat := logitsMat(t, []float32{0.1, 0.9, 0.2, 0.0})
+		idx, conf := argmaxConstrained(mat, 0, alphabet, nil)
+		// The logit is stored as float32, so compare against 0.9 widened
+		// from float32 rather than the float64 literal.
+		if idx != 1 || conf != float64(float32(0.9)) {
+			t.Errorf("argmaxConstrained = (%d, %v), want (1, %v)", idx, conf, float64(float32(0.9)))
+		}
+	})
+
+	t.Run("constraint skips disallowed characters but not the blank", func(t *testing.T) {
+		mat := logitsMat(t, []float32{0.1, 0.9, 0.2, 0.0})
+		constraint := &AlphabetConstraint{Charset: "C"}
+		idx, conf := argmaxConstrained(mat, 0, alphabet, constraint)
+		// A (0.9) and B (0.2) are skipped; the blank (0.1) stays a
+		// candidate and beats the allowed C (0.0).
+		if idx != 0 || conf != float64(float32(0.1)) {
+			t.Errorf("argmaxConstrained = (%d, %v), want (0, %v)", idx, conf, float64(float32(0.1)))
+		}
+	})
+
+	t.Run("blank wins when nothing clears the constraint", func(t *testing.T) {
+		mat := logitsMat(t, []float32{0.5, 0.9, 0.2, 0.1})
+		constraint := &AlphabetConstraint{Charset: "Z"}
+		idx, _ := argmaxConstrained(mat, 0, alphabet, constraint)
+		if idx != 0 {
+			t.Errorf("argmaxConstrained index = %d, want 0 (blank)", idx)
+		}
+	})
+}
+
+func TestCTCCollapse(t *testing.T) {
+	alphabet := []rune("-ABC")
+
+	cases := []struct {
+		name       string
+		indices    []int
+		confs      []float64
+		constraint *AlphabetConstraint
+		wantText   string
+		wantConf   float64
+	}{
+		{
+			name:     "collapses repeats and drops blanks",
+			indices:  []int{0, 1, 1, 0, 2, 2, 2, 3},
+			confs:    []float64{0, 0.8, 0.9, 0, 0.6, 0.7, 0.8, 1.0},
+			wantText: "ABC",
+			wantConf: 0.8, // (0.8 + 0.6 + 1.0) / 3
+		},
+		{
+			name:     "repeat separated by a blank is kept twice",
+			indices:  []int{1, 0, 1},
+			confs:    []float64{0.5, 0, 0.5},
+			wantText: "AA",
+			wantConf: 0.5,
+		},
+		{
+			name:       "truncated to the constraint length",
+			indices:    []int{1, 2, 3},
+			confs:      []float64{1, 1, 1},
+			constraint: &AlphabetConstraint{Length: 2},
+			wantText:   "AB",
+			wantConf:   1,
+		},
+		{
+			name:     "all blank decodes to empty with zero confidence",
+			indices:  []int{0, 0, 0},
+			confs:    []float64{0, 0, 0},
+			wantText: "",
+			wantConf: 0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			text, conf := ctcCollapse(c.indices, c.confs, alphabet, c.constraint)
+			if text != c.wantText {
+				t.Errorf("text = %q, want %q", text, c.wantText)
+			}
+			// The mean is computed at runtime in float64, e.g.
+			// (0.8+0.6+1.0)/3 gives 0.7999999999999999.
+			if math.Abs(conf-c.wantConf) > 1e-12 {
+				t.Errorf("confidence = %v, want %v", conf, c.wantConf)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package ocr
+
+import (
+	"image"
+	"math"
+
+	"gocv.io/x/gocv"
+)
+
+// getRotateCropImage crops and rectifies a rotated quadrilateral out of
+// img into an upright rectangle, mirroring PaddleOCR's
+// get_rotate_crop_image: the destination width/height are derived from
+// the quad's own edge lengths (rather than its bounding box) so a
+// tightly rotated line is not padded with background, and the crop is
+// rotated 90° when it comes out at least 1.5 times taller than wide.
+func getRotateCropImage(img gocv.Mat, box Quad) (gocv.Mat, error) {
+	width, height, rotate := rectifyDims(box)
+	if width <= 0 || height <= 0 {
+		return gocv.NewMat(), errInvalidBox
+	}
+
+	src := gocv.NewPoint2fVectorFromPoints([]gocv.Point2f{
+		{X: float32(box[0].X), Y: float32(box[0].Y)},
+		{X: float32(box[1].X), Y: float32(box[1].Y)},
+		{X: float32(box[2].X), Y: float32(box[2].Y)},
+		{X: float32(box[3].X), Y: float32(box[3].Y)},
+	})
+	defer src.Close()
+
+	dst := gocv.NewPoint2fVectorFromPoints([]gocv.Point2f{
+		{X: 0, Y: 0},
+		{X: float32(width), Y: 0},
+		{X: float32(width), Y: float32(height)},
+		{X: 0, Y: float32(height)},
+	})
+	defer dst.Close()
+
+	transform := gocv.GetPerspectiveTransform(src, dst)
+	defer transform.Close()
+
+	warped := gocv.NewMat()
+	gocv.WarpPerspective(img, &warped, transform, image.Pt(width, height))
+
+	if rotate {
+		rotated := gocv.NewMat()
+		gocv.Rotate(warped, &rotated, gocv.Rotate90Clockwise)
+		warped.Close()
+		return rotated, nil
+	}
+
+	return warped, nil
+}
+
+// rectifyDims derives the destination width/height for a rotated quad
+// from its own edge lengths (rather than its bounding box), and
+// whether the warped crop should be rotated 90° afterward because its
+// height is at least 1.5 times its width.
+func rectifyDims(box Quad) (width, height int, rotate bool) {
+	width = int(math.Max(dist(box[0], box[1]), dist(box[2], box[3])))
+	height = int(math.Max(dist(box[0], box[3]), dist(box[1], box[2])))
+	rotate = float64(height) >= float64(width)*1.5
+	return width, height, rotate
+}
+
+func dist(a, b Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
@@ -0,0 +1,44 @@
+package ocr
+
+import "testing"
+
+func TestRectifyDims(t *testing.T) {
+	cases := []struct {
+		name                  string
+		box                   Quad
+		wantWidth, wantHeight int
+		wantRotate            bool
+	}{
+		{
+			name:       "wide text line stays upright",
+			box:        Quad{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 20}, {X: 0, Y: 20}},
+			wantWidth:  100,
+			wantHeight: 20,
+			wantRotate: false,
+		},
+		{
+			name:       "tall crop is rotated",
+			box:        Quad{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 100}, {X: 0, Y: 100}},
+			wantWidth:  20,
+			wantHeight: 100,
+			wantRotate: true,
+		},
+		{
+			name:       "just under the 1.5x rotate margin stays upright",
+			box:        Quad{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 29}, {X: 0, Y: 29}},
+			wantWidth:  20,
+			wantHeight: 29,
+			wantRotate: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			width, height, rotate := rectifyDims(c.box)
+			if width != c.wantWidth || height != c.wantHeight || rotate != c.wantRotate {
+				t.Errorf("rectifyDims(%v) = (%d, %d, %v), want (%d, %d, %v)",
+					c.box, width, height, rotate, c.wantWidth, c.wantHeight, c.wantRotate)
+			}
+		})
+	}
+}
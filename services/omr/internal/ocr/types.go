@@ -0,0 +1,64 @@
+// Package ocr implements a small PaddleOCR-style text recognition
+// pipeline used to read the handwritten NOMBRE and CURP fields off a
+// scanned answer sheet: text detection, orientation classification and
+// sequence recognition, each swappable behind an interface so the
+// default DNN-backed implementations can be replaced in tests or by a
+// future model upgrade without touching the calling code.
+package ocr
+
+import "gocv.io/x/gocv"
+
+// Point is a single 2D coordinate in image space.
+type Point struct {
+	X, Y float64
+}
+
+// Quad is a rotated quadrilateral text box, ordered top-left,
+// top-right, bottom-right, bottom-left.
+type Quad [4]Point
+
+// Detection is one candidate text region found by a TextDetector.
+type Detection struct {
+	Box        Quad
+	Confidence float64
+}
+
+// Recognition is the decoded text for a single rectified crop.
+type Recognition struct {
+	Text       string
+	Confidence float64
+}
+
+// TextDetector finds candidate text regions in an image. Boxes are
+// returned as rotated quadrilaterals rather than axis-aligned rects so
+// that callers can unwarp skewed handwriting before recognition.
+type TextDetector interface {
+	Detect(img gocv.Mat) ([]Detection, error)
+}
+
+// TextClassifier decides whether a rectified text-line crop is upside
+// down (rotated ~180°) and needs to be flipped before recognition.
+type TextClassifier interface {
+	IsRotated(crop gocv.Mat) (bool, float64, error)
+}
+
+// TextRecognizer reads the string contained in a rectified text-line
+// crop, returning the decoded text and its confidence.
+type TextRecognizer interface {
+	Recognize(crop gocv.Mat) (Recognition, error)
+}
+
+// AlphabetConstraint restricts a TextRecognizer to a fixed character
+// set and length, used for structured fields like CURP where the
+// format is known in advance.
+type AlphabetConstraint struct {
+	Charset string
+	Length  int
+}
+
+// CURPConstraint is the 18-character, A-Z0-9 alphabet used by the
+// Mexican CURP identifier.
+var CURPConstraint = AlphabetConstraint{
+	Charset: "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+	Length:  18,
+}
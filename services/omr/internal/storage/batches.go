@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBatchNotFound is returned by GetBatch/CancelBatch when id does
+// not match a stored batch. ListBatchFiles returns an empty list
+// instead.
+var ErrBatchNotFound = errors.New("storage: batch not found")
+
+// BatchFileStatus is the lifecycle of one file within a Batch.
+type BatchFileStatus string
+
+const (
+	BatchFilePending BatchFileStatus = "pending"
+	BatchFileDone    BatchFileStatus = "done"
+	BatchFileFailed  BatchFileStatus = "failed"
+)
+
+// BatchStatus is the lifecycle of a batch as a whole.
+type BatchStatus string
+
+const (
+	BatchRunning  BatchStatus = "running"
+	BatchDone     BatchStatus = "done"
+	BatchCanceled BatchStatus = "canceled"
+)
+
+// Batch is one bulk-upload grading job: a set of files graded against
+// the same AnswerKey and worked off by a bounded pool of goroutines.
+type Batch struct {
+	ID        string      `json:"id"`
+	KeyID     string      `json:"keyId"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Status    BatchStatus `json:"status"`
+	Total     int         `json:"total"`
+	Done      int         `json:"done"`
+	Failed    int         `json:"failed"`
+}
+
+// BatchFile is one image within a Batch. Path is where the original
+// upload was saved to disk so a restart can requeue it; it isn't part
+// of the wire format.
+type BatchFile struct {
+	BatchID  string          `json:"-"`
+	Name     string          `json:"name"`
+	Path     string          `json:"-"`
+	Status   BatchFileStatus `json:"status"`
+	ResultID string          `json:"resultId,omitempty"`
+	Score    int             `json:"score,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+const batchesSchema = `
+CREATE TABLE IF NOT EXISTS batches (
+	id         TEXT PRIMARY KEY,
+	key_id     TEXT,
+	created_at TIMESTAMP NOT NULL,
+	status     TEXT NOT NULL DEFAULT 'running',
+	total      INTEGER NOT NULL DEFAULT 0,
+	done       INTEGER NOT NULL DEFAULT 0,
+	failed     INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS batch_files (
+	batch_id  TEXT NOT NULL REFERENCES batches(id) ON DELETE CASCADE,
+	name      TEXT NOT NULL,
+	path      TEXT NOT NULL,
+	status    TEXT NOT NULL DEFAULT 'pending',
+	result_id TEXT,
+	score     INTEGER,
+	error     TEXT,
+	PRIMARY KEY (batch_id, name)
+);
+`
+
+// CreateBatch persists a new batch and its file list, all initially
+// pending, in one transaction.
+func (s *Store) CreateBatch(batch Batch, files []BatchFile) (Batch, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Batch{}, err
+	}
+	defer tx.Rollback()
+
+	batch.CreatedAt = time.Now().UTC()
+	batch.Status = BatchRunning
+	batch.Total = len(files)
+	if _, err := tx.Exec(
+		`INSERT INTO batches (id, key_id, created_at, status, total, done, failed) VALUES (?, ?, ?, ?, ?, 0, 0)`,
+		batch.ID, batch.KeyID, batch.CreatedAt, batch.Status, batch.Total,
+	); err != nil {
+		return Batch{}, fmt.Errorf("storage: create batch: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO batch_files (batch_id, name, path, status) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return Batch{}, fmt.Errorf("storage: create batch: %w", err)
+	}
+	defer stmt.Close()
+	for _, f := range files {
+		if _, err := stmt.Exec(batch.ID, f.Name, f.Path, BatchFilePending); err != nil {
+			return Batch{}, fmt.Errorf("storage: create batch: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Batch{}, err
+	}
+	return batch, nil
+}
+
+// GetBatch loads a batch's summary row by id.
+func (s *Store) GetBatch(id string) (Batch, error) {
+	row := s.db.QueryRow(`SELECT id, key_id, created_at, status, total, done, failed FROM batches WHERE id = ?`, id)
+
+	var b Batch
+	if err := row.Scan(&b.ID, &b.KeyID, &b.CreatedAt, &b.Status, &b.Total, &b.Done, &b.Failed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Batch{}, ErrBatchNotFound
+		}
+		return Batch{}, fmt.Errorf("storage: get batch: %w", err)
+	}
+	return b, nil
+}
+
+// ListRunningBatches returns every batch still marked running, so a
+// freshly started service can requeue their pending files.
+func (s *Store) ListRunningBatches() ([]Batch, error) {
+	rows, err := s.db.Query(
+		`SELECT id, key_id, created_at, status, total, done, failed FROM batches WHERE status = ?`, BatchRunning)
+	if err != nil {
+		return nil, fmt.Errorf("storage: list running batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []Batch
+	for rows.Next() {
+		var b Batch
+		if err := rows.Scan(&b.ID, &b.KeyID, &b.CreatedAt, &b.Status, &b.Total, &b.Done, &b.Failed); err != nil {
+			return nil, fmt.Errorf("storage: list running batches: %w", err)
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+// ListBatchFiles returns every file in batch id, in upload order.
+func (s *Store) ListBatchFiles(batchID string) ([]BatchFile, error) {
+	rows, err := s.db.Query(
+		`SELECT batch_id, name, path, status, result_id, score, error FROM batch_files WHERE batch_id = ? ORDER BY rowid`,
+		batchID)
+	if err != nil {
+		return nil, fmt.Errorf("storage: list batch files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []BatchFile
+	for rows.Next() {
+		var f BatchFile
+		var resultID, errMsg sql.NullString
+		var score sql.NullInt64
+		if err := rows.Scan(&f.BatchID, &f.Name, &f.Path, &f.Status, &resultID, &score, &errMsg); err != nil {
+			return nil, fmt.Errorf("storage: list batch files: %w", err)
+		}
+		f.ResultID = resultID.String
+		f.Score = int(score.Int64)
+		f.Error = errMsg.String
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// CompleteBatchFile records the outcome of one file (fileErr empty on
+// success) and bumps the batch's done/failed counter accordingly.
+func (s *Store) CompleteBatchFile(batchID, name, resultID string, score int, fileErr string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status := BatchFileDone
+	if fileErr != "" {
+		status = BatchFileFailed
+	}
+	if _, err := tx.Exec(
+		`UPDATE batch_files SET status = ?, result_id = ?, score = ?, error = ? WHERE batch_id = ? AND name = ?`,
+		status, resultID, score, fileErr, batchID, name,
+	); err != nil {
+		return fmt.Errorf("storage: complete batch file: %w", err)
+	}
+
+	if status == BatchFileDone {
+		_, err = tx.Exec(`UPDATE batches SET done = done + 1 WHERE id = ?`, batchID)
+	} else {
+		_, err = tx.Exec(`UPDATE batches SET failed = failed + 1 WHERE id = ?`, batchID)
+	}
+	if err != nil {
+		return fmt.Errorf("storage: complete batch file: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// FinishBatch marks a batch done once every file has been attempted.
+// It's a no-op if the batch was already canceled.
+func (s *Store) FinishBatch(id string) error {
+	if _, err := s.db.Exec(
+		`UPDATE batches SET status = ? WHERE id = ? AND status = ?`, BatchDone, id, BatchRunning,
+	); err != nil {
+		return fmt.Errorf("storage: finish batch: %w", err)
+	}
+	return nil
+}
+
+// CancelBatch marks a batch canceled. It does not touch the file rows
+// itself: the worker pool checks the batch's context and records any
+// queued file that hasn't started yet as failed without processing it.
+func (s *Store) CancelBatch(id string) error {
+	res, err := s.db.Exec(`UPDATE batches SET status = ? WHERE id = ?`, BatchCanceled, id)
+	if err != nil {
+		return fmt.Errorf("storage: cancel batch: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBatchNotFound
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrKeyNotFound is returned by GetKey/UpdateKey/DeleteKey when id
+// does not match a stored answer key.
+var ErrKeyNotFound = errors.New("storage: answer key not found")
+
+// Question is one scored item in an AnswerKey. Correct may hold
+// several comma-separated choices for a multi-select question (e.g.
+// "A,C"); an empty Correct marks the question as ungraded.
+type Question struct {
+	Num     int      `json:"num"`
+	Correct string   `json:"correct"`
+	Points  float64  `json:"points"`
+	Choices []string `json:"choices"`
+}
+
+// BubbleLayout tunes the contour-based bubble-grid detector for a
+// given sheet: how many rows/choices to expect and the radius and
+// fill-ratio thresholds that decide whether a candidate blob is a
+// bubble, and whether it's filled.
+type BubbleLayout struct {
+	Rows          int     `json:"rows"`
+	ChoicesPerRow int     `json:"choicesPerRow"`
+	MinRadius     int     `json:"minRadius"`
+	MaxRadius     int     `json:"maxRadius"`
+	FillThreshold float64 `json:"fillThreshold"`
+}
+
+// DefaultBubbleLayout is used for any AnswerKey that doesn't specify
+// its own layout.
+var DefaultBubbleLayout = BubbleLayout{
+	Rows:          10,
+	ChoicesPerRow: 5,
+	MinRadius:     10,
+	MaxRadius:     40,
+	FillThreshold: 0.55,
+}
+
+// AnswerKey is a scoring rubric: one Question per graded item, in the
+// order a bubble sheet presents them.
+type AnswerKey struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Questions []Question   `json:"questions"`
+	Layout    BubbleLayout `json:"layout"`
+}
+
+// CreateKey persists key, assigning CreatedAt. key.ID must already be
+// set by the caller (the HTTP layer generates it).
+func (s *Store) CreateKey(key AnswerKey) (AnswerKey, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return AnswerKey{}, err
+	}
+	defer tx.Rollback()
+
+	key.CreatedAt = time.Now().UTC()
+	layout := withDefaultLayout(key.Layout)
+	if _, err := tx.Exec(
+		`INSERT INTO keys (id, name, created_at, rows, choices_per_row, min_radius, max_radius, fill_threshold)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, key.CreatedAt, layout.Rows, layout.ChoicesPerRow, layout.MinRadius, layout.MaxRadius, layout.FillThreshold,
+	); err != nil {
+		return AnswerKey{}, fmt.Errorf("storage: create key: %w", err)
+	}
+	key.Layout = layout
+
+	if err := insertQuestions(tx, key.ID, key.Questions); err != nil {
+		return AnswerKey{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return AnswerKey{}, err
+	}
+	return key, nil
+}
+
+// GetKey loads an answer key and its questions by id.
+func (s *Store) GetKey(id string) (AnswerKey, error) {
+	row := s.db.QueryRow(
+		`SELECT id, name, created_at, rows, choices_per_row, min_radius, max_radius, fill_threshold
+		 FROM keys WHERE id = ?`, id)
+
+	var key AnswerKey
+	if err := row.Scan(&key.ID, &key.Name, &key.CreatedAt, &key.Layout.Rows, &key.Layout.ChoicesPerRow,
+		&key.Layout.MinRadius, &key.Layout.MaxRadius, &key.Layout.FillThreshold); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return AnswerKey{}, ErrKeyNotFound
+		}
+		return AnswerKey{}, fmt.Errorf("storage: get key: %w", err)
+	}
+
+	questions, err := s.questionsForKey(id)
+	if err != nil {
+		return AnswerKey{}, err
+	}
+	key.Questions = questions
+	return key, nil
+}
+
+// ListKeys returns every stored answer key, without its questions
+// (use GetKey for the full rubric).
+func (s *Store) ListKeys() ([]AnswerKey, error) {
+	rows, err := s.db.Query(
+		`SELECT id, name, created_at, rows, choices_per_row, min_radius, max_radius, fill_threshold
+		 FROM keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("storage: list keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []AnswerKey
+	for rows.Next() {
+		var key AnswerKey
+		if err := rows.Scan(&key.ID, &key.Name, &key.CreatedAt, &key.Layout.Rows, &key.Layout.ChoicesPerRow,
+			&key.Layout.MinRadius, &key.Layout.MaxRadius, &key.Layout.FillThreshold); err != nil {
+			return nil, fmt.Errorf("storage: list keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// withDefaultLayout fills in any zero-valued layout fields from
+// DefaultBubbleLayout, so creating a key without a layout still gets
+// sane detector tunables.
+func withDefaultLayout(layout BubbleLayout) BubbleLayout {
+	if layout.Rows == 0 {
+		layout.Rows = DefaultBubbleLayout.Rows
+	}
+	if layout.ChoicesPerRow == 0 {
+		layout.ChoicesPerRow = DefaultBubbleLayout.ChoicesPerRow
+	}
+	if layout.MinRadius == 0 {
+		layout.MinRadius = DefaultBubbleLayout.MinRadius
+	}
+	if layout.MaxRadius == 0 {
+		layout.MaxRadius = DefaultBubbleLayout.MaxRadius
+	}
+	if layout.FillThreshold == 0 {
+		layout.FillThreshold = DefaultBubbleLayout.FillThreshold
+	}
+	return layout
+}
+
+// UpdateKey replaces the name and questions of an existing key.
+func (s *Store) UpdateKey(key AnswerKey) (AnswerKey, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return AnswerKey{}, err
+	}
+	defer tx.Rollback()
+
+	layout := withDefaultLayout(key.Layout)
+	res, err := tx.Exec(
+		`UPDATE keys SET name = ?, rows = ?, choices_per_row = ?, min_radius = ?, max_radius = ?, fill_threshold = ?
+		 WHERE id = ?`,
+		key.Name, layout.Rows, layout.ChoicesPerRow, layout.MinRadius, layout.MaxRadius, layout.FillThreshold, key.ID,
+	)
+	if err != nil {
+		return AnswerKey{}, fmt.Errorf("storage: update key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return AnswerKey{}, ErrKeyNotFound
+	}
+	key.Layout = layout
+
+	if _, err := tx.Exec(`DELETE FROM questions WHERE key_id = ?`, key.ID); err != nil {
+		return AnswerKey{}, fmt.Errorf("storage: update key: %w", err)
+	}
+	if err := insertQuestions(tx, key.ID, key.Questions); err != nil {
+		return AnswerKey{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return AnswerKey{}, err
+	}
+	return key, nil
+}
+
+// DeleteKey removes an answer key and its questions.
+func (s *Store) DeleteKey(id string) error {
+	res, err := s.db.Exec(`DELETE FROM keys WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("storage: delete key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+func (s *Store) questionsForKey(keyID string) ([]Question, error) {
+	rows, err := s.db.Query(
+		`SELECT num, correct, points, choices FROM questions WHERE key_id = ? ORDER BY num`, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("storage: load questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []Question
+	for rows.Next() {
+		var q Question
+		var choices string
+		if err := rows.Scan(&q.Num, &q.Correct, &q.Points, &choices); err != nil {
+			return nil, fmt.Errorf("storage: load questions: %w", err)
+		}
+		q.Choices = strings.Split(choices, ",")
+		questions = append(questions, q)
+	}
+	return questions, rows.Err()
+}
+
+func insertQuestions(tx *sql.Tx, keyID string, questions []Question) error {
+	stmt, err := tx.Prepare(`INSERT INTO questions (key_id, num, correct, points, choices) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("storage: save questions: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, q := range questions {
+		choices := q.Choices
+		if len(choices) == 0 {
+			choices = []string{"A", "B", "C", "D", "E"}
+		}
+		if _, err := stmt.Exec(keyID, q.Num, q.Correct, q.Points, strings.Join(choices, ",")); err != nil {
+			return fmt.Errorf("storage: save questions: %w", err)
+		}
+	}
+	return nil
+}
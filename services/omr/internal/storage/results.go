@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Result is one persisted grading, enough to redisplay or re-export
+// the submission without re-running OCR/OMR on the original image.
+type Result struct {
+	ID           string    `json:"id"`
+	ReceivedAt   time.Time `json:"receivedAt"`
+	KeyID        string    `json:"keyId"`
+	Nombre       string    `json:"nombre"`
+	CURP         string    `json:"curp"`
+	Score        int       `json:"score"`
+	Total        int       `json:"total"`
+	ProcessingMs int64     `json:"processingMs"`
+	ImageBlobRef string    `json:"imageBlobRef"`
+	RawJSON      string    `json:"-"`
+}
+
+// ResultQuery filters ListResults. Curp and Name are matched
+// fuzzily via the results_fts FTS5 index; KeyID, From and To are
+// exact/range filters on the results table itself.
+type ResultQuery struct {
+	CURP   string
+	Name   string
+	KeyID  string
+	From   time.Time
+	To     time.Time
+	Cursor string // opaque: the ID of the last row from the previous page
+	Limit  int
+}
+
+const resultsSchema = `
+CREATE TABLE IF NOT EXISTS results (
+	id             TEXT PRIMARY KEY,
+	received_at    TIMESTAMP NOT NULL,
+	key_id         TEXT,
+	nombre         TEXT,
+	curp           TEXT,
+	score          INTEGER,
+	total          INTEGER,
+	processing_ms  INTEGER,
+	image_blob_ref TEXT,
+	raw_json       TEXT
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS results_fts USING fts5(
+	id UNINDEXED, nombre, curp
+);
+`
+
+// SaveResult persists a grading result and indexes its identity
+// fields in results_fts for fuzzy lookup.
+func (s *Store) SaveResult(r Result) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO results
+		(id, received_at, key_id, nombre, curp, score, total, processing_ms, image_blob_ref, raw_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.ReceivedAt, r.KeyID, r.Nombre, r.CURP, r.Score, r.Total, r.ProcessingMs, r.ImageBlobRef, r.RawJSON)
+	if err != nil {
+		return fmt.Errorf("storage: save result: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO results_fts (id, nombre, curp) VALUES (?, ?, ?)`,
+		r.ID, r.Nombre, r.CURP); err != nil {
+		return fmt.Errorf("storage: index result: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetResult loads a single result by id, including its raw JSON.
+func (s *Store) GetResult(id string) (Result, error) {
+	row := s.db.QueryRow(`SELECT id, received_at, key_id, nombre, curp, score, total, processing_ms, image_blob_ref, raw_json
+		FROM results WHERE id = ?`, id)
+
+	var r Result
+	if err := row.Scan(&r.ID, &r.ReceivedAt, &r.KeyID, &r.Nombre, &r.CURP, &r.Score, &r.Total,
+		&r.ProcessingMs, &r.ImageBlobRef, &r.RawJSON); err != nil {
+		return Result{}, fmt.Errorf("storage: get result: %w", err)
+	}
+	return r, nil
+}
+
+// ListResults returns results matching q, newest first, along with
+// the cursor to pass back in for the next page (empty when exhausted).
+func (s *Store) ListResults(q ResultQuery) ([]Result, string, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var (
+		joins []string
+		where []string
+		args  []interface{}
+	)
+
+	if q.CURP != "" || q.Name != "" {
+		var terms []string
+		if term := ftsColumnQuery("curp", q.CURP); term != "" {
+			terms = append(terms, term)
+		}
+		if term := ftsColumnQuery("nombre", q.Name); term != "" {
+			terms = append(terms, term)
+		}
+		// A filter made only of characters ftsEscape strips (e.g. "***")
+		// escapes to zero usable terms; an empty FTS5 MATCH string is a syntax
+		// error, not a no-match, so skip the clause entirely rather than
+		// sending one.
+		if len(terms) > 0 {
+			joins = append(joins, `JOIN results_fts ON results_fts.id = results.id`)
+			where = append(where, "results_fts MATCH ?")
+			args = append(args, strings.Join(terms, " OR "))
+		}
+	}
+	if q.KeyID != "" {
+		where = append(where, "results.key_id = ?")
+		args = append(args, q.KeyID)
+	}
+	if !q.From.IsZero() {
+		where = append(where, "results.received_at >= ?")
+		args = append(args, q.From)
+	}
+	if !q.To.IsZero() {
+		where = append(where, "results.received_at <= ?")
+		args = append(args, q.To)
+	}
+	if q.Cursor != "" {
+		where = append(where, `results.received_at <= (SELECT received_at FROM results WHERE id = ?)
+			AND results.id != ?`)
+		args = append(args, q.Cursor, q.Cursor)
+	}
+
+	query := "SELECT results.id, results.received_at, results.key_id, results.nombre, results.curp, " +
+		"results.score, results.total, results.processing_ms, results.image_blob_ref, results.raw_json " +
+		"FROM results " + strings.Join(joins, " ")
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY results.received_at DESC, results.id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("storage: list results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var r Result
+		if err := rows.Scan(&r.ID, &r.ReceivedAt, &r.KeyID, &r.Nombre, &r.CURP, &r.Score, &r.Total,
+			&r.ProcessingMs, &r.ImageBlobRef, &r.RawJSON); err != nil {
+			return nil, "", fmt.Errorf("storage: list results: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(results) > limit {
+		nextCursor = results[limit-1].ID
+		results = results[:limit]
+	}
+	return results, nextCursor, nil
+}
+
+// ftsEscape strips characters that have special meaning in an FTS5
+// query string, since CURP/name filters come straight from the user.
+func ftsEscape(s string) string {
+	replacer := strings.NewReplacer(`"`, "", "*", "", ":", "", "(", "", ")", "")
+	return replacer.Replace(s)
+}
+
+// ftsColumnQuery builds a column-scoped FTS5 MATCH term for value,
+// e.g. ftsColumnQuery("nombre", "Juan Perez") -> "nombre:(Juan* Perez*)".
+// Each whitespace-separated token is escaped and given its own prefix
+// match, and the whole set is grouped in parens so a multi-word value
+// stays scoped to column instead of the second token leaking into an
+// unscoped, cross-column match. Returns "" if value has no usable
+// tokens.
+func ftsColumnQuery(column, value string) string {
+	var tokens []string
+	for _, tok := range strings.Fields(value) {
+		if escaped := ftsEscape(tok); escaped != "" {
+			tokens = append(tokens, escaped+"*")
+		}
+	}
+	if len(tokens) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:(%s)", column, strings.Join(tokens, " "))
+}
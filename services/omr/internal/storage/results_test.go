@@ -0,0 +1,37 @@
+package storage
+
+import "testing"
+
+func TestFtsColumnQuery(t *testing.T) {
+	cases := []struct {
+		column, value, want string
+	}{
+		{"nombre", "Juan", "nombre:(Juan*)"},
+		{"nombre", "Juan Perez", "nombre:(Juan* Perez*)"},
+		{"curp", `AB"C*:(1)`, "curp:(ABC1*)"},
+		{"nombre", "   ", ""},
+		{"nombre", "", ""},
+	}
+
+	for _, c := range cases {
+		if got := ftsColumnQuery(c.column, c.value); got != c.want {
+			t.Errorf("ftsColumnQuery(%q, %q) = %q, want %q", c.column, c.value, got, c.want)
+		}
+	}
+}
+
+func TestFtsEscape(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{`hello`, `hello`},
+		{`he"llo`, `hello`},
+		{`a*b`, `ab`},
+		{`col:val`, `colval`},
+		{`(grouped)`, `grouped`},
+	}
+
+	for _, c := range cases {
+		if got := ftsEscape(c.in); got != c.want {
+			t.Errorf("ftsEscape(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
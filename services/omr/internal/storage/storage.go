@@ -0,0 +1,82 @@
+// Package storage wraps the SQLite-backed persistence used by the OMR
+// service: answer keys, the results index, and batch upload state all
+// live in the same database.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite" // pure-Go driver; avoids a CGO conflict with gocv's OpenCV bindings
+)
+
+// Store owns the database handle and exposes feature-specific methods
+// (see keys.go) on top of it.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens (creating if necessary) the SQLite database at path and
+// applies any pending schema migrations.
+func Open(path string) (*Store, error) {
+	// SQLite enforces foreign keys (and thus ON DELETE CASCADE) per
+	// connection, off by default, and database/sql may open more than
+	// one connection against *sql.DB under concurrent callers (the
+	// batch worker pool in particular). A one-time `PRAGMA` Exec after
+	// open only configures whichever single connection runs it, so the
+	// pragma has to ride in the DSN instead: modernc.org/sqlite applies
+	// it to every connection it opens.
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("storage: open %q: %w", path, err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("storage: ping %q: %w", path, err)
+	}
+
+	store := &Store{db: db}
+	if err := store.migrate(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Close releases the underlying database handle.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS keys (
+	id              TEXT PRIMARY KEY,
+	name            TEXT NOT NULL,
+	created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	rows            INTEGER NOT NULL DEFAULT 0,
+	choices_per_row INTEGER NOT NULL DEFAULT 5,
+	min_radius      INTEGER NOT NULL DEFAULT 10,
+	max_radius      INTEGER NOT NULL DEFAULT 40,
+	fill_threshold  REAL NOT NULL DEFAULT 0.55
+);
+
+CREATE TABLE IF NOT EXISTS questions (
+	key_id  TEXT NOT NULL REFERENCES keys(id) ON DELETE CASCADE,
+	num     INTEGER NOT NULL,
+	correct TEXT NOT NULL,
+	points  REAL NOT NULL DEFAULT 1,
+	choices TEXT NOT NULL DEFAULT 'A,B,C,D,E',
+	PRIMARY KEY (key_id, num)
+);
+`
+
+func (s *Store) migrate() error {
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("storage: migrate: %w", err)
+	}
+	if _, err := s.db.Exec(resultsSchema); err != nil {
+		return fmt.Errorf("storage: migrate: %w", err)
+	}
+	if _, err := s.db.Exec(batchesSchema); err != nil {
+		return fmt.Errorf("storage: migrate: %w", err)
+	}
+	return nil
+}
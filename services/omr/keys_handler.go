@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/storage"
+)
+
+// KeysAPI exposes CRUD over AnswerKeys, backed by a storage.Store.
+type KeysAPI struct {
+	store *storage.Store
+}
+
+// NewKeysAPI builds a KeysAPI over store.
+func NewKeysAPI(store *storage.Store) *KeysAPI {
+	return &KeysAPI{store: store}
+}
+
+// keySpec is the wire format for POST /keys and PUT /keys/{id}; it
+// omits the server-assigned createdAt. id is optional on POST (one is
+// generated when it's empty) and ignored on PUT, which uses the path's id.
+type keySpec struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	Questions []storage.Question   `json:"questions"`
+	Layout    storage.BubbleLayout `json:"layout"`
+}
+
+// Collection handles GET /keys and POST /keys.
+func (a *KeysAPI) Collection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := a.store.ListKeys()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, keys)
+
+	case http.MethodPost:
+		var spec keySpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		id := spec.ID
+		if id == "" {
+			id = fmt.Sprintf("key_%d", time.Now().UnixNano())
+		}
+
+		key, err := a.store.CreateKey(storage.AnswerKey{ID: id, Name: spec.Name, Questions: spec.Questions, Layout: spec.Layout})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, key)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Item handles GET/PUT/DELETE /keys/{id}.
+func (a *KeysAPI) Item(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/keys/")
+	if id == "" {
+		http.Error(w, "missing key id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		key, err := a.store.GetKey(id)
+		if err != nil {
+			writeKeyError(w, err)
+			return
+		}
+		writeJSON(w, key)
+
+	case http.MethodPut:
+		var spec keySpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		key, err := a.store.UpdateKey(storage.AnswerKey{ID: id, Name: spec.Name, Questions: spec.Questions, Layout: spec.Layout})
+		if err != nil {
+			writeKeyError(w, err)
+			return
+		}
+		writeJSON(w, key)
+
+	case http.MethodDelete:
+		if err := a.store.DeleteKey(id); err != nil {
+			writeKeyError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeKeyError(w http.ResponseWriter, err error) {
+	if err == storage.ErrKeyNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
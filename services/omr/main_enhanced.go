@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -8,21 +9,47 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
 
 	"gocv.io/x/gocv"
+
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/ocr"
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/storage"
 )
 
 // Enhanced result structure with region detection
 type ProcessingResult struct {
-	Score      int                `json:"score"`
-	Answers    []string           `json:"answers"`
-	Total      int                `json:"total"`
-	Regions    RegionBoundingBoxes `json:"regions"`
-	Quality    ImageQualityMetrics `json:"quality"`
-	ProcessingTime int64            `json:"processingTimeMs"`
+	Score          int                 `json:"score"`
+	Answers        []string            `json:"answers"`
+	Total          int                 `json:"total"`
+	Regions        RegionBoundingBoxes `json:"regions"`
+	Quality        ImageQualityMetrics `json:"quality"`
+	ProcessingTime int64               `json:"processingTimeMs"`
+
+	// Identity fields read off the sheet by the OCR pipeline.
+	Nombre           string  `json:"nombre"`
+	NombreConfidence float64 `json:"nombreConfidence"`
+	CURP             string  `json:"curp"`
+	CURPConfidence   float64 `json:"curpConfidence"`
+
+	// Homography maps original-photo coordinates into the rectified
+	// image; its Invert maps region boxes and bubble coordinates back to
+	// the original photo, so they can be reported in both spaces.
+	Homography *Homography `json:"homography,omitempty"`
+
+	// KeyID is the AnswerKey this result was scored against, and
+	// Details/AwardedPoints/PossiblePoints give the weighted,
+	// per-question breakdown behind Score/Total.
+	KeyID          string           `json:"keyId,omitempty"`
+	Details        []QuestionDetail `json:"details,omitempty"`
+	AwardedPoints  float64          `json:"awardedPoints"`
+	PossiblePoints float64          `json:"possiblePoints"`
+
+	// ResultID is set once the result has been persisted via saveResult.
+	ResultID string `json:"resultId,omitempty"`
 }
 
 type RegionBoundingBoxes struct {
@@ -48,19 +75,38 @@ type ImageQualityMetrics struct {
 }
 
 type DocumentProcessor struct {
-	debug bool
-	mutex sync.RWMutex
+	debug    bool
+	mutex    sync.RWMutex
+	ocr      *ocr.Pipeline
+	store    *storage.Store
+	imageDir string
 }
 
-func NewDocumentProcessor(debug bool) *DocumentProcessor {
+// NewDocumentProcessor builds a processor. ocrPipeline may be nil, in
+// which case identity-field extraction is skipped and Nombre/CURP are
+// left blank (useful for environments without the OCR models on disk).
+// imageDir is where submitted images are saved so /results/{id}/overlay.png
+// can redraw them later.
+func NewDocumentProcessor(debug bool, ocrPipeline *ocr.Pipeline, store *storage.Store, imageDir string) *DocumentProcessor {
 	return &DocumentProcessor{
-		debug: debug,
+		debug:    debug,
+		ocr:      ocrPipeline,
+		store:    store,
+		imageDir: imageDir,
 	}
 }
 
-func (dp *DocumentProcessor) ProcessDocument(imgData []byte) (*ProcessingResult, error) {
+// ProcessDocument runs the full pipeline: rectify, region detection,
+// identity OCR, then OMR scoring. ctx is checked between stages so a
+// canceled batch job (see batch.go) stops before its next expensive
+// step rather than running to completion regardless.
+func (dp *DocumentProcessor) ProcessDocument(ctx context.Context, imgData []byte, key storage.AnswerKey) (*ProcessingResult, error) {
 	startTime := time.Now()
-	
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Decode image
 	img, err := gocv.IMDecode(imgData, gocv.IMReadColor)
 	if err != nil {
@@ -75,15 +121,49 @@ func (dp *DocumentProcessor) ProcessDocument(imgData []byte) (*ProcessingResult,
 	// Get image quality metrics
 	result.Quality = dp.analyzeImageQuality(&img)
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Rectify the page: deskew/perspective-correct before any
+	// region or bubble coordinates are computed.
+	rectified, homography, err := dp.rectify(&img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rectify image: %v", err)
+	}
+	defer rectified.Close()
+	result.Homography = homography
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Detect and segment regions
-	regions, err := dp.detectRegions(&img)
+	regions, err := dp.detectRegions(&rectified)
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect regions: %v", err)
 	}
 	result.Regions = regions
 
+	// Read the handwritten identity fields via the OCR pipeline.
+	if dp.ocr != nil {
+		nombre := dp.readField(&rectified, regions.Nombre, nil)
+		result.Nombre = nombre.Text
+		result.NombreConfidence = nombre.Confidence
+
+		curp := dp.readField(&rectified, regions.CURP, &ocr.CURPConstraint)
+		result.CURP = curp.Text
+		result.CURPConfidence = curp.Confidence
+	}
+
+	result.KeyID = key.ID
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Process OMR section for bubble detection
-	omrResults, err := dp.processOMRSection(&img, regions.OMR)
+	omrResults, err := dp.processOMRSection(&rectified, regions.OMR, key)
 	if err != nil {
 		log.Printf("Warning: OMR processing failed: %v", err)
 		// Continue with empty results rather than failing completely
@@ -94,6 +174,9 @@ func (dp *DocumentProcessor) ProcessDocument(imgData []byte) (*ProcessingResult,
 		result.Answers = omrResults.Answers
 		result.Score = omrResults.Score
 		result.Total = omrResults.Total
+		result.Details = omrResults.Details
+		result.AwardedPoints = omrResults.Awarded
+		result.PossiblePoints = omrResults.Possible
 	}
 
 	result.ProcessingTime = time.Since(startTime).Milliseconds()
@@ -208,13 +291,31 @@ func (dp *DocumentProcessor) detectRegions(img *gocv.Mat) (RegionBoundingBoxes,
 	return regions, nil
 }
 
+// readField crops img to box and runs it through the OCR pipeline,
+// logging and swallowing any recognition error so a bad identity
+// field never fails the whole document.
+func (dp *DocumentProcessor) readField(img *gocv.Mat, box BoundingBox, constraint *ocr.AlphabetConstraint) ocr.FieldResult {
+	roi := img.Region(image.Rect(box.X, box.Y, box.X+box.Width, box.Y+box.Height))
+	defer roi.Close()
+
+	field, err := dp.ocr.ReadField(roi, constraint)
+	if err != nil {
+		log.Printf("Warning: OCR field read failed: %v", err)
+		return ocr.FieldResult{}
+	}
+	return field
+}
+
 type OMRResults struct {
-	Answers []string
-	Score   int
-	Total   int
+	Answers  []string
+	Score    int
+	Total    int
+	Awarded  float64
+	Possible float64
+	Details  []QuestionDetail
 }
 
-func (dp *DocumentProcessor) processOMRSection(img *gocv.Mat, omrRegion BoundingBox) (*OMRResults, error) {
+func (dp *DocumentProcessor) processOMRSection(img *gocv.Mat, omrRegion BoundingBox, key storage.AnswerKey) (*OMRResults, error) {
 	// Extract OMR region
 	roi := img.Region(image.Rect(
 		omrRegion.X,
@@ -229,79 +330,34 @@ func (dp *DocumentProcessor) processOMRSection(img *gocv.Mat, omrRegion Bounding
 	defer gray.Close()
 	gocv.CvtColor(roi, &gray, gocv.ColorBGRToGray)
 
-	// Apply adaptive threshold
-	binary := gocv.NewMat()
-	defer binary.Close()
-	gocv.AdaptiveThreshold(gray, &binary, 255, gocv.AdaptiveThresholdMean, gocv.ThresholdBinary, 11, 2)
-
-	// Detect circles using HoughCircles
-	circles := gocv.NewMat()
-	defer circles.Close()
-	
-	gocv.HoughCircles(
-		gray,
-		&circles,
-		gocv.HoughGradient,
-		1,    // dp
-		30,   // minDist
-		100,  // param1
-		30,   // param2
-		10,   // minRadius
-		40,   // maxRadius
-	)
-
-	// Process detected circles
-	answers := make([]string, 10) // Assume 10 questions for demo
-	correctAnswers := []string{"A", "B", "C", "D", "A", "B", "C", "D", "A", "B"}
-	
-	if circles.Cols() > 0 {
-		// Group circles by rows and determine answers
-		answers = dp.processBubbleGrid(circles, roi.Cols(), roi.Rows())
+	// Locate and score the bubble grid: contour candidates, clustered
+	// into rows/columns and decided by fill ratio rather than cycling
+	// through a fixed answer list.
+	layout := key.Layout
+	if layout.Rows == 0 {
+		layout = storage.DefaultBubbleLayout
 	}
+	answers := gradeBubbleGrid(gray, layout)
+
+	// Score against the selected AnswerKey: weighted points,
+	// multi-select and omit handling all live in scoreAgainstKey.
+	awarded, possible, details := scoreAgainstKey(answers, key)
 
-	// Calculate score
 	score := 0
-	total := len(correctAnswers)
-	for i, answer := range answers {
-		if i < len(correctAnswers) && answer == correctAnswers[i] {
-			score++
-		}
-	}
-	
-	if total > 0 {
-		score = (score * 100) / total
+	if possible > 0 {
+		score = int(awarded / possible * 100)
 	}
 
 	return &OMRResults{
-		Answers: answers,
-		Score:   score,
-		Total:   total,
+		Answers:  answers,
+		Score:    score,
+		Total:    len(key.Questions),
+		Awarded:  awarded,
+		Possible: possible,
+		Details:  details,
 	}, nil
 }
 
-func (dp *DocumentProcessor) processBubbleGrid(circles gocv.Mat, width, height int) []string {
-	// This is a simplified implementation
-	// In production, you would:
-	// 1. Group circles by rows and columns
-	// 2. Determine which bubbles are filled based on intensity
-	// 3. Map positions to question numbers and answer choices
-	
-	answers := make([]string, 10)
-	choices := []string{"A", "B", "C", "D"}
-	
-	// Mock processing for demo
-	for i := 0; i < 10; i++ {
-		if i < circles.Rows() {
-			// Simplified: just cycle through choices
-			answers[i] = choices[i%4]
-		} else {
-			answers[i] = "A" // Default
-		}
-	}
-	
-	return answers
-}
-
 // HTTP handlers
 func (dp *DocumentProcessor) gradeHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -342,8 +398,20 @@ func (dp *DocumentProcessor) gradeHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Resolve the AnswerKey to score against. An unset keyId scores
+	// against an empty key (every question awarded zero) rather than
+	// failing the request outright.
+	var key storage.AnswerKey
+	if keyID := r.FormValue("keyId"); keyID != "" {
+		key, err = dp.store.GetKey(keyID)
+		if err != nil {
+			writeKeyError(w, err)
+			return
+		}
+	}
+
 	// Process document
-	result, err := dp.ProcessDocument(fileData)
+	result, err := dp.ProcessDocument(r.Context(), fileData, key)
 	if err != nil {
 		log.Printf("❌ Processing failed: %v", err)
 		http.Error(w, fmt.Sprintf("Processing failed: %v", err), http.StatusInternalServerError)
@@ -352,11 +420,53 @@ func (dp *DocumentProcessor) gradeHandler(w http.ResponseWriter, r *http.Request
 
 	log.Printf("✅ Processing completed in %dms - Score: %d%%", result.ProcessingTime, result.Score)
 
+	if resultID, err := dp.saveResult(header.Filename, fileData, result); err != nil {
+		log.Printf("⚠️  Failed to save result to index: %v", err)
+	} else {
+		result.ResultID = resultID
+	}
+
 	// Send response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
 
+// saveResult writes the submitted image to imageDir and records the
+// grading in the results index so it can later be searched, fetched
+// by id, or rendered as an overlay. It returns the generated result id.
+func (dp *DocumentProcessor) saveResult(filename string, fileData []byte, result *ProcessingResult) (string, error) {
+	id := fmt.Sprintf("res_%d", time.Now().UnixNano())
+
+	imagePath := filepath.Join(dp.imageDir, id+filepath.Ext(filename))
+	if err := os.MkdirAll(dp.imageDir, 0o755); err != nil {
+		return "", fmt.Errorf("create image dir: %w", err)
+	}
+	if err := os.WriteFile(imagePath, fileData, 0o644); err != nil {
+		return "", fmt.Errorf("write image: %w", err)
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("marshal result: %w", err)
+	}
+
+	if err := dp.store.SaveResult(storage.Result{
+		ID:           id,
+		ReceivedAt:   time.Now().UTC(),
+		KeyID:        result.KeyID,
+		Nombre:       result.Nombre,
+		CURP:         result.CURP,
+		Score:        result.Score,
+		Total:        result.Total,
+		ProcessingMs: result.ProcessingTime,
+		ImageBlobRef: imagePath,
+		RawJSON:      string(raw),
+	}); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	
@@ -380,6 +490,35 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(health)
 }
 
+// buildOCRPipeline wires up the default DNN-backed OCR stages from
+// model paths supplied via environment variables. It returns a nil
+// pipeline and an error when the models aren't configured; main logs
+// that error and the service still runs with identity-field extraction
+// disabled.
+func buildOCRPipeline() (*ocr.Pipeline, error) {
+	detPath := os.Getenv("OCR_DET_MODEL")
+	clsPath := os.Getenv("OCR_CLS_MODEL")
+	recPath := os.Getenv("OCR_REC_MODEL")
+	if detPath == "" || clsPath == "" || recPath == "" {
+		return nil, fmt.Errorf("OCR_DET_MODEL, OCR_CLS_MODEL and OCR_REC_MODEL must all be set")
+	}
+
+	detector, err := ocr.NewDBDetector(detPath, 960)
+	if err != nil {
+		return nil, err
+	}
+	classifier, err := ocr.NewDirectionClassifier(clsPath)
+	if err != nil {
+		return nil, err
+	}
+	recognizer, err := ocr.NewCRNNRecognizer(recPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ocr.NewPipeline(detector, classifier, recognizer), nil
+}
+
 func main() {
 	// Configure OpenCV
 	if gocv.Version() == "" {
@@ -390,13 +529,48 @@ func main() {
 	log.Printf("📊 OpenCV version: %s", gocv.Version())
 	log.Printf("💻 Go version: %s", runtime.Version())
 
+	// Initialize the answer-key store
+	keys, err := storage.Open(answerKeyDBPath())
+	if err != nil {
+		log.Fatalf("❌ Failed to open answer key store: %v", err)
+	}
+	defer keys.Close()
+	keysAPI := NewKeysAPI(keys)
+
 	// Initialize processor
 	debug := os.Getenv("DEBUG") == "true"
-	processor := NewDocumentProcessor(debug)
+	ocrPipeline, err := buildOCRPipeline()
+	if err != nil {
+		log.Printf("⚠️  OCR pipeline disabled: %v", err)
+	}
+	imageDir := os.Getenv("RESULTS_IMAGE_DIR")
+	if imageDir == "" {
+		imageDir = "results_images"
+	}
+	processor := NewDocumentProcessor(debug, ocrPipeline, keys, imageDir)
+	resultsAPI := NewResultsAPI(keys, imageDir, processor)
+
+	// Initialize the batch processor and requeue anything left running
+	// from before the last restart.
+	uploadDir := os.Getenv("BATCH_UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "batch_uploads"
+	}
+	batchProcessor := NewBatchProcessor(keys, processor, uploadDir)
+	if err := batchProcessor.RequeuePending(); err != nil {
+		log.Printf("⚠️  Failed to requeue pending batches: %v", err)
+	}
+	batchesAPI := NewBatchesAPI(batchProcessor, keys)
 
 	// Setup routes
 	http.HandleFunc("/grade", processor.gradeHandler)
 	http.HandleFunc("/health", healthHandler)
+	http.HandleFunc("/keys", keysAPI.Collection)
+	http.HandleFunc("/keys/", keysAPI.Item)
+	http.HandleFunc("/results", resultsAPI.Collection)
+	http.HandleFunc("/results/", resultsAPI.Item)
+	http.HandleFunc("/batches", batchesAPI.Collection)
+	http.HandleFunc("/batches/", batchesAPI.Item)
 
 	// Add CORS middleware for all routes
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -421,6 +595,9 @@ func main() {
 	log.Printf("🎯 Endpoints available:")
 	log.Printf("   POST /grade - Process document with region detection")
 	log.Printf("   GET  /health - Service health check")
+	log.Printf("   GET|POST /keys, GET|PUT|DELETE /keys/{id} - Manage answer keys")
+	log.Printf("   GET /results, GET /results/{id}, GET /results/{id}/overlay.png - Query past submissions")
+	log.Printf("   POST /batches, GET|DELETE /batches/{id}, GET /batches/{id}/stream - Bulk grading jobs")
 	
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
 		log.Fatal("❌ Server failed to start:", err)
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"gocv.io/x/gocv"
+)
+
+// Homography is a 3x3 perspective transform, stored row-major so it
+// round-trips through JSON; it lets callers map a region box or bubble
+// coordinate computed in rectified space back into the original photo.
+type Homography [3][3]float64
+
+// rectify locates the answer sheet's page boundary in img and warps it
+// into a canonical, upright view so that the fixed-percentage region
+// boxes in detectRegions line up even on an angled phone-camera photo.
+// When no page boundary can be found, it falls back to a simple
+// rotation using the skew angle calculateSkew already computes.
+func (dp *DocumentProcessor) rectify(img *gocv.Mat) (gocv.Mat, *Homography, error) {
+	quad, found := dp.findPageQuad(img)
+	if !found {
+		return dp.rectifyBySkew(img)
+	}
+
+	corners := orderCorners(quad)
+	width, height := quadDimensions(corners)
+
+	src := gocv.NewPoint2fVectorFromPoints([]gocv.Point2f{
+		{X: float32(corners[0].X), Y: float32(corners[0].Y)},
+		{X: float32(corners[1].X), Y: float32(corners[1].Y)},
+		{X: float32(corners[2].X), Y: float32(corners[2].Y)},
+		{X: float32(corners[3].X), Y: float32(corners[3].Y)},
+	})
+	defer src.Close()
+
+	dst := gocv.NewPoint2fVectorFromPoints([]gocv.Point2f{
+		{X: 0, Y: 0},
+		{X: float32(width), Y: 0},
+		{X: float32(width), Y: float32(height)},
+		{X: 0, Y: float32(height)},
+	})
+	defer dst.Close()
+
+	transform := gocv.GetPerspectiveTransform(src, dst)
+	defer transform.Close()
+
+	rectified := gocv.NewMat()
+	gocv.WarpPerspective(*img, &rectified, transform, image.Pt(width, height))
+
+	return rectified, homographyFromMat(transform), nil
+}
+
+// findPageQuad looks for the largest 4-point contour in img, which on
+// a photographed answer sheet is almost always the page edge.
+func (dp *DocumentProcessor) findPageQuad(img *gocv.Mat) ([]image.Point, bool) {
+	gray := gocv.NewMat()
+	defer gray.Close()
+	gocv.CvtColor(*img, &gray, gocv.ColorBGRToGray)
+
+	thresh := gocv.NewMat()
+	defer thresh.Close()
+	gocv.AdaptiveThreshold(gray, &thresh, 255, gocv.AdaptiveThresholdGaussian, gocv.ThresholdBinaryInv, 11, 2)
+
+	closed := gocv.NewMat()
+	defer closed.Close()
+	kernel := gocv.GetStructuringElement(gocv.MorphRect, image.Pt(9, 9))
+	defer kernel.Close()
+	gocv.MorphologyEx(thresh, &closed, gocv.MorphClose, kernel)
+
+	contours := gocv.FindContours(closed, gocv.RetrievalExternal, gocv.ChainApproxSimple)
+	defer contours.Close()
+
+	var bestQuad []image.Point
+	bestArea := 0.0
+
+	for i := 0; i < contours.Size(); i++ {
+		contour := contours.At(i)
+		area := gocv.ContourArea(contour)
+		if area < float64(img.Cols()*img.Rows())/4 {
+			continue // page should cover a large fraction of the frame
+		}
+
+		perimeter := gocv.ArcLength(contour, true)
+		approx := gocv.ApproxPolyDP(contour, 0.02*perimeter, true)
+
+		if approx.Size() == 4 && area > bestArea {
+			bestArea = area
+			bestQuad = approx.ToPoints()
+		}
+	}
+
+	return bestQuad, bestQuad != nil
+}
+
+// rectifyBySkew is the fallback path when no clean page quad is found:
+// it rotates the image by the Hough-line skew angle already computed
+// by calculateSkew.
+func (dp *DocumentProcessor) rectifyBySkew(img *gocv.Mat) (gocv.Mat, *Homography, error) {
+	gray := gocv.NewMat()
+	defer gray.Close()
+	gocv.CvtColor(*img, &gray, gocv.ColorBGRToGray)
+
+	angle := dp.calculateSkew(&gray)
+	center := image.Pt(img.Cols()/2, img.Rows()/2)
+	rotMat := gocv.GetRotationMatrix2D(center, angle, 1.0)
+	defer rotMat.Close()
+
+	rotated := gocv.NewMat()
+	gocv.WarpAffine(*img, &rotated, rotMat, image.Pt(img.Cols(), img.Rows()))
+
+	return rotated, homographyFromAffine(rotMat), nil
+}
+
+// orderCorners sorts four arbitrary quad points into top-left,
+// top-right, bottom-right, bottom-left order: top-left has the
+// smallest x+y, bottom-right the largest x+y, top-right the smallest
+// y-x, and bottom-left the largest y-x.
+func orderCorners(quad []image.Point) [4]image.Point {
+	sum := make([]int, len(quad))
+	diff := make([]int, len(quad))
+	for i, p := range quad {
+		sum[i] = p.X + p.Y
+		diff[i] = p.Y - p.X
+	}
+
+	byIndex := func(less func(i, j int) bool) image.Point {
+		idx := make([]int, len(quad))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.Slice(idx, func(i, j int) bool { return less(idx[i], idx[j]) })
+		return quad[idx[0]]
+	}
+
+	var ordered [4]image.Point
+	ordered[0] = byIndex(func(i, j int) bool { return sum[i] < sum[j] })  // top-left
+	ordered[2] = byIndex(func(i, j int) bool { return sum[i] > sum[j] })  // bottom-right
+	ordered[1] = byIndex(func(i, j int) bool { return diff[i] < diff[j] }) // top-right
+	ordered[3] = byIndex(func(i, j int) bool { return diff[i] > diff[j] }) // bottom-left
+
+	return ordered
+}
+
+// quadDimensions computes the destination width/height for a
+// perspective warp as the max of each pair of opposite edge lengths.
+func quadDimensions(c [4]image.Point) (int, int) {
+	widthTop := distPt(c[0], c[1])
+	widthBottom := distPt(c[3], c[2])
+	heightLeft := distPt(c[0], c[3])
+	heightRight := distPt(c[1], c[2])
+
+	return int(math.Max(widthTop, widthBottom)), int(math.Max(heightLeft, heightRight))
+}
+
+func distPt(a, b image.Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+// homographyFromMat reads a 3x3 gocv.Mat (as returned by
+// GetPerspectiveTransform) into a JSON-serializable Homography.
+func homographyFromMat(m gocv.Mat) *Homography {
+	var h Homography
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			h[r][c] = m.GetDoubleAt(r, c)
+		}
+	}
+	return &h
+}
+
+// homographyFromAffine embeds a 2x3 affine matrix (as returned by
+// GetRotationMatrix2D) into a full 3x3 homography with an identity
+// bottom row.
+func homographyFromAffine(m gocv.Mat) *Homography {
+	var h Homography
+	for r := 0; r < 2; r++ {
+		for c := 0; c < 3; c++ {
+			h[r][c] = m.GetDoubleAt(r, c)
+		}
+	}
+	h[2] = [3]float64{0, 0, 1}
+	return &h
+}
+
+// Apply maps p through the homography in homogeneous coordinates. For
+// an h returned by rectify, p is a point in original-image space and
+// the result is the corresponding point in rectified space.
+func (h *Homography) Apply(p image.Point) image.Point {
+	x := h[0][0]*float64(p.X) + h[0][1]*float64(p.Y) + h[0][2]
+	y := h[1][0]*float64(p.X) + h[1][1]*float64(p.Y) + h[1][2]
+	w := h[2][0]*float64(p.X) + h[2][1]*float64(p.Y) + h[2][2]
+	if w == 0 {
+		w = 1
+	}
+	return image.Pt(int(x/w), int(y/w))
+}
+
+// Invert returns the homography that maps rectified-space coordinates
+// back to original-image space, so region boxes and bubble
+// coordinates computed against the rectified image can be drawn on
+// the original upload.
+func (h *Homography) Invert() *Homography {
+	a, b, c := h[0][0], h[0][1], h[0][2]
+	d, e, f := h[1][0], h[1][1], h[1][2]
+	g, hh, i := h[2][0], h[2][1], h[2][2]
+
+	det := a*(e*i-f*hh) - b*(d*i-f*g) + c*(d*hh-e*g)
+	if det == 0 {
+		return h
+	}
+	invDet := 1 / det
+
+	return &Homography{
+		{(e*i - f*hh) * invDet, (c*hh - b*i) * invDet, (b*f - c*e) * invDet},
+		{(f*g - d*i) * invDet, (a*i - c*g) * invDet, (c*d - a*f) * invDet},
+		{(d*hh - e*g) * invDet, (b*g - a*hh) * invDet, (a*e - b*d) * invDet},
+	}
+}
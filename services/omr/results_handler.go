@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gocv.io/x/gocv"
+
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/storage"
+)
+
+// ResultsAPI exposes the results index: listing/search, single-result
+// lookup, a rendered overlay image, and CSV export.
+type ResultsAPI struct {
+	store    *storage.Store
+	imageDir string
+	dp       *DocumentProcessor
+}
+
+// NewResultsAPI builds a ResultsAPI. imageDir is where original
+// submitted images are saved so overlay.png can redraw them later; dp
+// is reused by the overlay to re-rectify a stored image and redetect
+// its bubble candidates for rendering.
+func NewResultsAPI(store *storage.Store, imageDir string, dp *DocumentProcessor) *ResultsAPI {
+	return &ResultsAPI{store: store, imageDir: imageDir, dp: dp}
+}
+
+const dateLayout = "2006-01-02"
+
+// Collection handles GET /results, including ?format=csv exports.
+func (a *ResultsAPI) Collection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	query := storage.ResultQuery{
+		CURP:   q.Get("curp"),
+		Name:   q.Get("name"),
+		KeyID:  q.Get("key"),
+		Cursor: q.Get("cursor"),
+	}
+	if from := q.Get("from"); from != "" {
+		if t, err := time.Parse(dateLayout, from); err == nil {
+			query.From = t
+		}
+	}
+	if to := q.Get("to"); to != "" {
+		if t, err := time.Parse(dateLayout, to); err == nil {
+			query.To = t
+		}
+	}
+	if limit, err := strconv.Atoi(q.Get("limit")); err == nil {
+		query.Limit = limit
+	}
+
+	results, nextCursor, err := a.store.ListResults(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if q.Get("format") == "csv" {
+		writeResultsCSV(w, results)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"results":    results,
+		"nextCursor": nextCursor,
+	})
+}
+
+func writeResultsCSV(w http.ResponseWriter, results []storage.Result) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="gradebook.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "received_at", "key_id", "nombre", "curp", "score", "total"})
+	for _, r := range results {
+		cw.Write([]string{
+			r.ID,
+			r.ReceivedAt.Format(time.RFC3339),
+			r.KeyID,
+			r.Nombre,
+			r.CURP,
+			strconv.Itoa(r.Score),
+			strconv.Itoa(r.Total),
+		})
+	}
+	cw.Flush()
+}
+
+// Item handles GET /results/{id} and GET /results/{id}/overlay.png.
+func (a *ResultsAPI) Item(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/results/")
+	if strings.HasSuffix(path, "/overlay.png") {
+		a.overlay(w, strings.TrimSuffix(path, "/overlay.png"))
+		return
+	}
+
+	result, err := a.store.GetResult(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// The row's raw_json is the full ProcessingResult captured at
+	// grading time; return that rather than the slimmer index row.
+	var full ProcessingResult
+	if err := json.Unmarshal([]byte(result.RawJSON), &full); err != nil {
+		http.Error(w, fmt.Sprintf("corrupt stored result: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, full)
+}
+
+// overlay renders the original submitted image with the detected
+// regions and recognized identity fields drawn on top.
+func (a *ResultsAPI) overlay(w http.ResponseWriter, id string) {
+	result, err := a.store.GetResult(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var full ProcessingResult
+	if err := json.Unmarshal([]byte(result.RawJSON), &full); err != nil {
+		http.Error(w, fmt.Sprintf("corrupt stored result: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	img := gocv.IMRead(result.ImageBlobRef, gocv.IMReadColor)
+	if img.Empty() {
+		http.Error(w, "original image not available", http.StatusNotFound)
+		return
+	}
+	defer img.Close()
+
+	// Regions and bubble coordinates were computed against the
+	// rectified image; invert the stored homography to map them back
+	// onto the original upload we're drawing on.
+	var inv *Homography
+	if full.Homography != nil {
+		inv = full.Homography.Invert()
+	}
+
+	drawRegion(&img, full.Regions.Nombre, fmt.Sprintf("NOMBRE: %s", full.Nombre), inv)
+	drawRegion(&img, full.Regions.CURP, fmt.Sprintf("CURP: %s", full.CURP), inv)
+	drawRegion(&img, full.Regions.OMR, fmt.Sprintf("Score: %d/%d", full.Score, full.Total), inv)
+	a.drawBubbles(&img, full, inv)
+
+	buf, err := gocv.IMEncode(gocv.PNGFileExt, img)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode overlay: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer buf.Close()
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.GetBytes())
+}
+
+// drawRegion outlines box and labels it. If inv is non-nil, box's
+// corners (given in rectified-image space) are mapped through it
+// before drawing, so the outline lands correctly on an original
+// photo that needed deskewing/perspective correction.
+func drawRegion(img *gocv.Mat, box BoundingBox, label string, inv *Homography) {
+	corners := []image.Point{
+		{box.X, box.Y},
+		{box.X + box.Width, box.Y},
+		{box.X + box.Width, box.Y + box.Height},
+		{box.X, box.Y + box.Height},
+	}
+	if inv != nil {
+		for i, p := range corners {
+			corners[i] = inv.Apply(p)
+		}
+	}
+
+	outline := gocv.NewPointVectorFromPoints(corners)
+	defer outline.Close()
+	gocv.Polylines(img, gocv.NewPointsVector([]gocv.PointVector{outline}), true, color.RGBA{R: 0, G: 200, B: 0, A: 255}, 2)
+
+	gocv.PutText(img, label, image.Pt(corners[0].X, corners[0].Y-8), gocv.FontHersheySimplex, 0.6,
+		color.RGBA{R: 0, G: 200, B: 0, A: 255}, 2)
+}
+
+// drawBubbles re-runs bubble-candidate detection over the stored
+// result's OMR region and plots each candidate as a circle, colored by
+// whether it cleared the key's fill threshold, so the overlay shows
+// actual detected bubbles and their fill state rather than just the
+// outer OMR box.
+func (a *ResultsAPI) drawBubbles(img *gocv.Mat, full ProcessingResult, inv *Homography) {
+	if a.dp == nil {
+		return
+	}
+
+	rectified, _, err := a.dp.rectify(img)
+	if err != nil {
+		return
+	}
+	defer rectified.Close()
+
+	omr := full.Regions.OMR
+	roi := rectified.Region(image.Rect(omr.X, omr.Y, omr.X+omr.Width, omr.Y+omr.Height))
+	defer roi.Close()
+
+	gray := gocv.NewMat()
+	defer gray.Close()
+	gocv.CvtColor(roi, &gray, gocv.ColorBGRToGray)
+
+	layout := storage.DefaultBubbleLayout
+	if full.KeyID != "" {
+		if key, err := a.store.GetKey(full.KeyID); err == nil && key.Layout.Rows > 0 {
+			layout = key.Layout
+		}
+	}
+
+	for _, c := range detectBubbleCandidates(gray, layout.MinRadius, layout.MaxRadius) {
+		center := image.Pt(c.Center.X+omr.X, c.Center.Y+omr.Y)
+		if inv != nil {
+			center = inv.Apply(center)
+		}
+
+		col := color.RGBA{R: 200, G: 0, B: 0, A: 255}
+		if c.FillRatio >= layout.FillThreshold {
+			col = color.RGBA{R: 0, G: 200, B: 0, A: 255}
+		}
+		gocv.Circle(img, center, c.Radius, col, 2)
+	}
+}
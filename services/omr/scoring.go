@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/storage"
+)
+
+// QuestionDetail is the per-question breakdown returned alongside the
+// aggregate score, so a caller can show which items were right,
+// wrong, omitted or multi-marked.
+type QuestionDetail struct {
+	Num     int     `json:"num"`
+	Marked  string  `json:"marked"`
+	Correct string  `json:"correct"`
+	Points  float64 `json:"points"`
+	Awarded float64 `json:"awarded"`
+}
+
+// omitPenaltyEnv configures, as a fraction of a question's points,
+// how much is deducted for leaving it blank. It defaults to 0:
+// omissions simply score zero rather than being penalized.
+const omitPenaltyEnv = "OMIT_PENALTY"
+
+// scoreAgainstKey grades answers against key, in question order,
+// supporting weighted points and multi-select questions (a
+// comma-separated set of correct choices).
+func scoreAgainstKey(answers []string, key storage.AnswerKey) (awarded, possible float64, details []QuestionDetail) {
+	penalty := omitPenalty()
+
+	for i, q := range key.Questions {
+		marked := ""
+		if i < len(answers) {
+			marked = answers[i]
+		}
+
+		// An empty Correct marks the question as ungraded: it is listed
+		// in details but adds nothing to possible or awarded.
+		if strings.TrimSpace(q.Correct) == "" {
+			details = append(details, QuestionDetail{Num: q.Num, Marked: marked, Points: q.Points})
+			continue
+		}
+
+		possible += q.Points
+		points := 0.0
+
+		switch {
+		case marked == "" || marked == "blank":
+			points = -penalty * q.Points
+		case answerSetsEqual(marked, q.Correct):
+			points = q.Points
+		}
+
+		awarded += points
+		details = append(details, QuestionDetail{
+			Num:     q.Num,
+			Marked:  marked,
+			Correct: q.Correct,
+			Points:  q.Points,
+			Awarded: points,
+		})
+	}
+
+	return awarded, possible, details
+}
+
+// answerSetsEqual compares two comma-separated choice sets
+// order-insensitively, so a multi-select answer of "C,A" matches a
+// key of "A,C".
+func answerSetsEqual(marked, correct string) bool {
+	return strings.Join(sortedChoices(marked), ",") == strings.Join(sortedChoices(correct), ",")
+}
+
+func sortedChoices(s string) []string {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(s)), ",")
+	choices := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			choices = append(choices, p)
+		}
+	}
+	sort.Strings(choices)
+	return choices
+}
+
+func omitPenalty() float64 {
+	raw := os.Getenv(omitPenaltyEnv)
+	if raw == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// defaultKeyStorePath is where the answer-key database lives unless
+// ANSWER_KEY_DB overrides it.
+const defaultKeyStorePath = "omr.db"
+
+func answerKeyDBPath() string {
+	if p := os.Getenv("ANSWER_KEY_DB"); p != "" {
+		return p
+	}
+	return defaultKeyStorePath
+}
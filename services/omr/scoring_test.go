@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/JazzDataSolutions/BubbleGrade/services/omr/internal/storage"
+)
+
+func TestScoreAgainstKey(t *testing.T) {
+	key := storage.AnswerKey{
+		Questions: []storage.Question{
+			{Num: 1, Correct: "A", Points: 1},
+			{Num: 2, Correct: "B", Points: 2},
+			{Num: 3, Correct: "A,C", Points: 1},
+		},
+	}
+
+	cases := []struct {
+		name    string
+		answers []string
+		want    float64
+	}{
+		{"all correct", []string{"A", "B", "C,A"}, 4},
+		{"one wrong", []string{"A", "A", "A,C"}, 2},
+		{"blank sentinel omitted", []string{"A", "blank", "A,C"}, 2},
+		{"empty string omitted", []string{"A", "", "A,C"}, 2},
+		{"missing trailing answers omitted", []string{"A"}, 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			awarded, possible, details := scoreAgainstKey(c.answers, key)
+			if awarded != c.want {
+				t.Errorf("awarded = %v, want %v", awarded, c.want)
+			}
+			if possible != 4 {
+				t.Errorf("possible = %v, want 4", possible)
+			}
+			if len(details) != len(key.Questions) {
+				t.Errorf("len(details) = %d, want %d", len(details), len(key.Questions))
+			}
+		})
+	}
+}
+
+func TestScoreAgainstKeyOmitPenalty(t *testing.T) {
+	t.Setenv(omitPenaltyEnv, "0.25")
+
+	key := storage.AnswerKey{
+		Questions: []storage.Question{
+			{Num: 1, Correct: "A", Points: 1},
+		},
+	}
+
+	awarded, _, _ := scoreAgainstKey([]string{"blank"}, key)
+	if awarded != -0.25 {
+		t.Errorf("awarded = %v, want -0.25", awarded)
+	}
+}
+
+func TestAnswerSetsEqual(t *testing.T) {
+	cases := []struct {
+		marked, correct string
+		want            bool
+	}{
+		{"A", "A", true},
+		{"C,A", "A,C", true},
+		{"a,c", "A,C", true},
+		{"A, C", "A,C", true},
+		{"A", "A,C", false},
+		{"", "A", false},
+	}
+
+	for _, c := range cases {
+		if got := answerSetsEqual(c.marked, c.correct); got != c.want {
+			t.Errorf("answerSetsEqual(%q, %q) = %v, want %v", c.marked, c.correct, got, c.want)
+		}
+	}
+}